@@ -0,0 +1,76 @@
+package actions
+
+import (
+	"errors"
+	"log"
+
+	"github.com/tavomoya/mangagram/actions/i18n"
+	"github.com/tavomoya/mangagram/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// maxCaptionLength is the maximum length
+// of a Telegram photo caption.
+const maxCaptionLength = 1024
+
+// Buttons of the new chapter alerts. Their Unique is fixed so a single
+// handler serves every alert, and their Data holds the alert's ID (Mark
+// read) or the ID of the subscription it comes from (Unsubscribe).
+var (
+	AlertMarkReadBtn    = tb.InlineButton{Unique: "alert_read"}
+	AlertUnsubscribeBtn = tb.InlineButton{Unique: "alert_unsub"}
+)
+
+// alertMarkup returns the buttons of a new chapter alert:
+// Read, which opens the chapter, Mark read and Unsubscribe.
+func alertMarkup(settings *models.ChatSettings, alert *models.QueuedAlert) *tb.ReplyMarkup {
+	kb := [][]tb.InlineButton{
+		{{Text: i18n.T(settings.Locale, "alert.read"), URL: alert.ChapterURL}},
+	}
+
+	if !alert.SubscriptionID.IsZero() {
+		read := AlertMarkReadBtn
+		read.Text = i18n.T(settings.Locale, "alert.mark_read")
+		read.Data = alert.ID.Hex()
+
+		unsub := AlertUnsubscribeBtn
+		unsub.Text = i18n.T(settings.Locale, "alert.unsubscribe")
+		unsub.Data = alert.SubscriptionID.Hex()
+
+		kb = append(kb, []tb.InlineButton{read, unsub})
+	}
+
+	return &tb.ReplyMarkup{InlineKeyboard: kb}
+}
+
+// MarkAlertRead method marks the chapter of an alert in the outbox
+// as the last one the user who read it has read in the subscription
+// the alert comes from, see MarkChapterRead.
+// Only the alerts sent to the chat are found.
+func MarkAlertRead(db *models.DatabaseConfig, chatID int64, alertID string, userID int) error {
+
+	if db == nil {
+		log.Println("The DB model is nil")
+		return errors.New("the DB model passed is nil, can't operate")
+	}
+
+	id, err := primitive.ObjectIDFromHex(alertID)
+	if err != nil {
+		log.Println("Invalid alert ID: ", alertID)
+		return err
+	}
+
+	alert := new(models.QueuedAlert)
+	err = db.MongoClient.Collection("outbox").FindOne(db.Ctx, bson.M{"_id": id, "chatid": chatID}).Decode(alert)
+	if err != nil {
+		log.Println("There was an error looking for the alert: ", err)
+		return err
+	}
+
+	chapter := &models.MangaChapter{URL: alert.ChapterURL, Number: alert.ChapterNumber}
+
+	return MarkChapterRead(db, userID, alert.ChatID, alert.SubscriptionID, chapter)
+}
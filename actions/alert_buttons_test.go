@@ -0,0 +1,85 @@
+package actions
+
+import (
+	"context"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/tavomoya/mangagram/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestAlertMarkup(t *testing.T) {
+	is := is.New(t)
+	settings := DefaultChatSettings(1)
+
+	alert := &models.QueuedAlert{
+		ID:             primitive.NewObjectID(),
+		SubscriptionID: primitive.NewObjectID(),
+		ChapterURL:     "https://example.com/1000",
+	}
+
+	kb := alertMarkup(settings, alert).InlineKeyboard
+	is.Equal(len(kb), 2)
+	is.Equal(kb[0][0].URL, "https://example.com/1000")
+	is.Equal(kb[1][0].Unique, AlertMarkReadBtn.Unique)
+	is.Equal(kb[1][0].Data, alert.ID.Hex())
+	is.Equal(kb[1][1].Unique, AlertUnsubscribeBtn.Unique)
+	is.Equal(kb[1][1].Data, alert.SubscriptionID.Hex())
+
+	// Alerts that don't come from a subscription only get the Read button
+	alert.SubscriptionID = primitive.NilObjectID
+	kb = alertMarkup(settings, alert).InlineKeyboard
+	is.Equal(len(kb), 1)
+}
+
+func TestMarkAlertRead(t *testing.T) {
+	opts := &mtest.Options{}
+	opts.ClientType(mtest.Mock)
+	opts.CollectionName("outbox")
+	opts.DatabaseName("mangagram")
+	opts.ShareClient(true)
+
+	mt := mtest.New(t, opts)
+	defer mt.Close()
+
+	is := is.New(t)
+	config := &models.DatabaseConfig{
+		Ctx:         context.Background(),
+		MongoClient: mt.Client.Database("mangagram"),
+	}
+
+	mt.Run("Nil Database", func(t *mtest.T) {
+		err := MarkAlertRead(nil, 1, primitive.NewObjectID().Hex(), 1)
+		is.True(err != nil)
+	})
+
+	mt.Run("Invalid alert ID", func(t *mtest.T) {
+		err := MarkAlertRead(config, 1, "abc123", 1)
+		is.True(err != nil)
+	})
+
+	mt.Run("Alert not found", func(t *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "outbox._id", mtest.FirstBatch))
+
+		err := MarkAlertRead(config, 1, primitive.NewObjectID().Hex(), 1)
+		is.True(err != nil)
+	})
+
+	mt.Run("Success", func(t *mtest.T) {
+		id := primitive.NewObjectID()
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "outbox._id", mtest.FirstBatch, bson.D{
+				{Key: "_id", Value: id},
+				{Key: "subscriptionid", Value: primitive.NewObjectID()},
+				{Key: "chapterurl", Value: "https://example.com/1000"},
+			}),
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}},
+		)
+
+		err := MarkAlertRead(config, 1, id.Hex(), 1)
+		is.NoErr(err)
+	})
+}
@@ -0,0 +1,171 @@
+package actions
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	"io"
+	"net/url"
+	"strings"
+	"text/template"
+
+	"github.com/tavomoya/mangagram/actions/i18n"
+	"github.com/tavomoya/mangagram/models"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// Modes of the alert templates. They set how the
+// alert is parsed by Telegram and how the values
+// in the template are escaped.
+const (
+	TemplateText     = "text"
+	TemplateHTML     = "html"
+	TemplateMarkdown = "markdownv2"
+)
+
+// TemplateModes defines the available template modes.
+var TemplateModes = []string{TemplateText, TemplateHTML, TemplateMarkdown}
+
+// formatModes defines the template mode of each built-in
+// notification format. Their templates are in the message
+// catalog, under the alert.template_<format> keys.
+var formatModes = map[string]string{
+	"default": TemplateText,
+	"compact": TemplateText,
+	"rich":    TemplateHTML,
+}
+
+// markdownEscaper escapes the characters that
+// are reserved in Telegram's MarkdownV2.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "_", `\_`, "*", `\*`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`,
+	"~", `\~`, "`", "\\`", ">", `\>`, "#", `\#`, "+", `\+`, "-", `\-`, "=", `\=`,
+	"|", `\|`, "{", `\{`, "}", `\}`, ".", `\.`, "!", `\!`,
+)
+
+// AlertData holds the values alert
+// templates have access to.
+type AlertData struct {
+	// Name of the manga
+	MangaName string
+
+	// URL to the manga
+	MangaURL string
+
+	// Chapter number, might be empty if it's unknown
+	Chapter string
+
+	// Title of the chapter, might be empty
+	ChapterTitle string
+
+	// URL to read the chapter
+	ChapterURL string
+
+	// Name of the feed the manga belongs to
+	FeedName string
+}
+
+// executor is implemented by both text
+// and HTML templates.
+type executor interface {
+	Execute(io.Writer, interface{}) error
+}
+
+// ParseAlertTemplate function checks an alert template can be used with
+// a mode. HTML templates escape the values they show depending on where
+// they are, MarkdownV2 templates escape every reserved character in them.
+func ParseAlertTemplate(text, mode string) error {
+	_, err := parseAlertTemplate(text, mode)
+	return err
+}
+
+// RenderAlert function returns the alert built from a template
+// and its data, escaping the data for the template's mode.
+func RenderAlert(text, mode string, data AlertData) (string, error) {
+	tmpl, err := parseAlertTemplate(text, mode)
+	if err != nil {
+		return "", err
+	}
+
+	if mode == TemplateMarkdown {
+		data = AlertData{
+			MangaName:    markdownEscaper.Replace(data.MangaName),
+			MangaURL:     markdownEscaper.Replace(data.MangaURL),
+			Chapter:      markdownEscaper.Replace(data.Chapter),
+			ChapterTitle: markdownEscaper.Replace(data.ChapterTitle),
+			ChapterURL:   markdownEscaper.Replace(data.ChapterURL),
+			FeedName:     markdownEscaper.Replace(data.FeedName),
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	err = tmpl.Execute(buf, data)
+	if err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// TemplateParseMode function returns the parse mode
+// messages built with a template mode are sent with.
+func TemplateParseMode(mode string) tb.ParseMode {
+	switch mode {
+	case TemplateHTML:
+		return tb.ModeHTML
+	case TemplateMarkdown:
+		return tb.ParseMode("MarkdownV2")
+	}
+
+	return tb.ModeDefault
+}
+
+// NewAlertData function returns the template
+// data of an alert in the outbox.
+func NewAlertData(alert *models.QueuedAlert) AlertData {
+	data := AlertData{
+		MangaName:    alert.MangaName,
+		MangaURL:     alert.MangaURL,
+		Chapter:      alert.ChapterNumber,
+		ChapterTitle: alert.ChapterTitle,
+		ChapterURL:   alert.ChapterURL,
+	}
+
+	for _, f := range AvailableFeeds {
+		if f.Code == alert.MangaFeed {
+			data.FeedName = f.Name
+			return data
+		}
+	}
+
+	// Feeds that aren't listed, like RSS ones,
+	// are named after the site they come from
+	if u, err := url.Parse(alert.MangaURL); err == nil {
+		data.FeedName = u.Hostname()
+	}
+
+	return data
+}
+
+// alertTemplate returns the template and mode a chat's alerts are
+// built with: its custom template, or the built-in one of its format.
+func alertTemplate(settings *models.ChatSettings) (string, string) {
+	if settings.Format == "custom" && settings.Template != "" {
+		return settings.Template, settings.TemplateMode
+	}
+
+	mode, ok := formatModes[settings.Format]
+	if !ok {
+		return i18n.T(settings.Locale, "alert.template_default"), TemplateText
+	}
+
+	return i18n.T(settings.Locale, "alert.template_"+settings.Format), mode
+}
+
+func parseAlertTemplate(text, mode string) (executor, error) {
+	if mode == TemplateHTML {
+		return htmltemplate.New("alert").Parse(text)
+	}
+
+	return template.New("alert").Parse(text)
+}
@@ -0,0 +1,74 @@
+package actions
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/tavomoya/mangagram/models"
+)
+
+func TestRenderAlert(t *testing.T) {
+	is := is.New(t)
+
+	data := AlertData{
+		MangaName:    "Kaguya-sama <Love is War>",
+		Chapter:      "200.5",
+		ChapterTitle: "Miko & Kaguya",
+		ChapterURL:   "https://example.com/read?c=200.5&l=en",
+		FeedName:     "Example",
+	}
+
+	msg, err := RenderAlert("{{.MangaName}} {{.Chapter}}", TemplateText, data)
+	is.NoErr(err)
+	is.Equal(msg, "Kaguya-sama <Love is War> 200.5")
+
+	msg, err = RenderAlert(`<b>{{.MangaName}}</b> <a href="{{.ChapterURL}}">{{.ChapterTitle}}</a>`, TemplateHTML, data)
+	is.NoErr(err)
+	is.Equal(msg, `<b>Kaguya-sama &lt;Love is War&gt;</b> <a href="https://example.com/read?c=200.5&amp;l=en">Miko &amp; Kaguya</a>`)
+
+	msg, err = RenderAlert(`*{{.MangaName}}* [{{.Chapter}}]({{.ChapterURL}})`, TemplateMarkdown, data)
+	is.NoErr(err)
+	is.Equal(msg, `*Kaguya\-sama <Love is War\>* [200\.5](https://example\.com/read?c\=200\.5&l\=en)`)
+
+	_, err = RenderAlert("{{.MangaName", TemplateText, data)
+	is.True(err != nil)
+
+	_, err = RenderAlert("{{.Missing}}", TemplateText, data)
+	is.True(err != nil)
+}
+
+func TestNewAlertData(t *testing.T) {
+	is := is.New(t)
+
+	data := NewAlertData(&models.QueuedAlert{
+		MangaName:     "One Piece",
+		MangaURL:      "https://mangadex.org/title/abc",
+		MangaFeed:     5,
+		ChapterURL:    "https://mangadex.org/chapter/def",
+		ChapterNumber: "1000",
+		ChapterTitle:  "Straw Hat Luffy",
+	})
+	is.Equal(data, AlertData{
+		MangaName:    "One Piece",
+		MangaURL:     "https://mangadex.org/title/abc",
+		Chapter:      "1000",
+		ChapterTitle: "Straw Hat Luffy",
+		ChapterURL:   "https://mangadex.org/chapter/def",
+		FeedName:     "Mangadex",
+	})
+
+	// RSS feeds are named after their site
+	data = NewAlertData(&models.QueuedAlert{MangaFeed: 6, MangaURL: "https://blog.example.com/feed.xml"})
+	is.Equal(data.FeedName, "blog.example.com")
+}
+
+func TestChapterNumber(t *testing.T) {
+	is := is.New(t)
+
+	is.Equal(ChapterNumber("https://manganelo.com/chapter/read_one_piece/chapter_1000"), "1000")
+	is.Equal(ChapterNumber("http://manga-reader.fun/one-piece/1000"), "1000")
+	is.Equal(ChapterNumber("One Piece Chapter 1000.5: Straw Hat"), "1000.5")
+	is.Equal(ChapterNumber("Season 3 Ep. 546"), "546")
+	is.Equal(ChapterNumber("Punch 3 is out"), "")
+	is.Equal(ChapterNumber(""), "")
+}
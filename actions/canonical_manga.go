@@ -0,0 +1,471 @@
+package actions
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/tavomoya/mangagram/actions/rss"
+	"github.com/tavomoya/mangagram/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// ErrAlreadySubscribed is returned when a chat subscribes
+// to a manga series it's already subscribed to.
+var ErrAlreadySubscribed = errors.New("the chat is already subscribed to this manga")
+
+// ErrNoFeedEntry is returned when a manga series
+// can't be found in the feed it's being moved to.
+var ErrNoFeedEntry = errors.New("the manga was not found in this feed")
+
+// NormalizeTitle function returns the form of a manga title used to
+// tell whether two titles are the same: lowercase, without accents,
+// punctuation or repeated spaces, e.g. "Kimetsu no Yaiba: Tanjirō"
+// becomes "kimetsu no yaiba tanjiro".
+func NormalizeTitle(title string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(t, title)
+	if err != nil {
+		folded = title
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(folded), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	return strings.Join(words, " ")
+}
+
+// normalizeTitles returns the normalized form of
+// every title, without duplicates or empty ones.
+func normalizeTitles(titles ...string) []string {
+	normalized := []string{}
+	for _, t := range titles {
+		if n := NormalizeTitle(t); n != "" && !contains(normalized, n) {
+			normalized = append(normalized, n)
+		}
+	}
+
+	return normalized
+}
+
+// contains reports whether list has item.
+func contains(list []string, item string) bool {
+	for _, i := range list {
+		if i == item {
+			return true
+		}
+	}
+
+	return false
+}
+
+// EnsureMangaIndexes method creates the unique index of the entries of
+// the manga series in the feeds, so an entry belongs to a single series.
+func EnsureMangaIndexes(db *models.DatabaseConfig) error {
+
+	if db == nil {
+		log.Println("The DB model is nil")
+		return errors.New("the DB model passed is nil, can't operate")
+	}
+
+	_, err := db.MongoClient.Collection("manga").Indexes().CreateOne(db.Ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "externalids", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("manga_externalids_unq"),
+	})
+	if err != nil {
+		log.Println("There was an error creating the manga indexes: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// findManga returns the manga series matching a
+// filter, or nil if there's none.
+func findManga(db *models.DatabaseConfig, filter bson.M) (*models.Manga, error) {
+	manga := new(models.Manga)
+
+	err := db.MongoClient.Collection("manga").FindOne(db.Ctx, filter).Decode(manga)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		log.Println("There was an error looking for the manga: ", err)
+		return nil, err
+	}
+
+	return manga, nil
+}
+
+// ResolveManga method returns the manga series a feed's title belongs to.
+// The series is looked up by the title's entry in the feed first. Different
+// series of a feed might share a title, so titles only match series without
+// an entry in the feed. The entry and titles are added to the series found,
+// or a new series is saved in the 'manga' collection if there's none.
+func ResolveManga(db *models.DatabaseConfig, feedCode int, mangaURL, title string, altTitles []string) (*models.Manga, error) {
+
+	if db == nil {
+		log.Println("The DB model is nil")
+		return nil, errors.New("the DB model passed is nil, can't operate")
+	}
+
+	if mangaURL == "" || title == "" {
+		log.Println("No manga supplied")
+		return nil, errors.New("no manga supplied")
+	}
+
+	titles := normalizeTitles(append([]string{title}, altTitles...)...)
+	entry := models.MangaExternalID{Feed: feedCode, URL: mangaURL}
+	collection := db.MongoClient.Collection("manga")
+	byEntry := bson.M{"externalids": bson.M{"$elemMatch": bson.M{"feed": feedCode, "url": mangaURL}}}
+
+	manga, err := findManga(db, byEntry)
+	if err != nil {
+		return nil, err
+	}
+
+	if manga == nil {
+		manga, err = findManga(db, bson.M{
+			"titles":           bson.M{"$in": titles},
+			"externalids.feed": bson.M{"$ne": feedCode},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if manga == nil {
+		manga = &models.Manga{
+			ID:          primitive.NewObjectID(),
+			Title:       title,
+			AltTitles:   altTitles,
+			Titles:      titles,
+			ExternalIDs: []models.MangaExternalID{entry},
+			CreatedAt:   time.Now(),
+		}
+
+		_, err = collection.InsertOne(db.Ctx, manga)
+		if mongo.IsDuplicateKeyError(err) {
+			// The entry was just saved with another series
+			return findManga(db, byEntry)
+		}
+		if err != nil {
+			log.Println("There was an error saving the manga: ", err)
+			return nil, err
+		}
+
+		return manga, nil
+	}
+
+	alts := []string{}
+	for _, t := range append([]string{title}, altTitles...) {
+		if t != manga.Title && !contains(manga.AltTitles, t) && !contains(alts, t) {
+			alts = append(alts, t)
+		}
+	}
+
+	_, err = collection.UpdateOne(
+		db.Ctx,
+		bson.M{"_id": manga.ID},
+		bson.M{"$addToSet": bson.M{
+			"externalids": entry,
+			"alttitles":   bson.M{"$each": alts},
+			"titles":      bson.M{"$each": titles},
+		}},
+	)
+	if err != nil {
+		log.Println("There was an error updating the manga: ", err)
+		return nil, err
+	}
+
+	manga.AltTitles = append(manga.AltTitles, alts...)
+	for _, t := range titles {
+		if !contains(manga.Titles, t) {
+			manga.Titles = append(manga.Titles, t)
+		}
+	}
+
+	if MangaEntry(manga, feedCode) == "" {
+		manga.ExternalIDs = append(manga.ExternalIDs, entry)
+	}
+
+	return manga, nil
+}
+
+// GetManga method returns a manga series by its ID.
+func GetManga(db *models.DatabaseConfig, id primitive.ObjectID) (*models.Manga, error) {
+
+	if db == nil {
+		log.Println("The DB model is nil")
+		return nil, errors.New("the DB model passed is nil, can't operate")
+	}
+
+	manga := new(models.Manga)
+	res := db.MongoClient.Collection("manga").FindOne(db.Ctx, bson.M{"_id": id})
+	err := res.Decode(manga)
+	if err != nil {
+		log.Println("There was an error getting the manga: ", err)
+		return nil, err
+	}
+
+	return manga, nil
+}
+
+// LinkSubscription method links a subscription to the manga series its
+// title belongs to, using the alt titles of the title's cached info if
+// there are any. Saved subscriptions are updated with the series' ID.
+func LinkSubscription(db *models.DatabaseConfig, sub *models.Subscription) error {
+
+	var altTitles []string
+	if info := CachedMangaInfo(db, sub.MangaURL); info != nil {
+		altTitles = info.AltTitles
+	}
+
+	manga, err := ResolveManga(db, sub.MangaFeed, sub.MangaURL, sub.MangaName, altTitles)
+	if err != nil {
+		return err
+	}
+
+	sub.MangaID = manga.ID
+	if sub.ID.IsZero() {
+		return nil
+	}
+
+	_, err = db.MongoClient.Collection("subscription").UpdateOne(
+		db.Ctx,
+		bson.M{"_id": sub.ID},
+		bson.M{"$set": bson.M{"mangaid": manga.ID}},
+	)
+	if err != nil {
+		log.Println("There was an error linking the subscription: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetChatMangaSubscription method returns the subscription of a Chat to
+// a manga series in any feed, or nil if the chat isn't subscribed to it.
+func GetChatMangaSubscription(db *models.DatabaseConfig, chatID int64, mangaID primitive.ObjectID) (*models.Subscription, error) {
+
+	if db == nil {
+		log.Println("The DB model is nil")
+		return nil, errors.New("the DB model passed is nil, can't operate")
+	}
+
+	sub := new(models.Subscription)
+	res := db.MongoClient.Collection("subscription").FindOne(db.Ctx, bson.M{"chatid": chatID, "mangaid": mangaID, "deleted_at": bson.M{"$exists": false}})
+	err := res.Decode(sub)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+
+		log.Println("There was an error looking for the chat's subscription: ", err)
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+// SubscribeManga method subscribes a Chat to a feed's title, see the
+// feed's Subscribe method, after linking the subscription to the title's
+// manga series. If the chat is already subscribed to the series, from
+// this title or another one, it returns that subscription and
+// ErrAlreadySubscribed; personal subscriptions of a group chat's
+// members are shared with the whole group instead.
+func SubscribeManga(db *models.DatabaseConfig, feed MangaFeedInterface, sub *models.Subscription) (*models.Subscription, error) {
+
+	// Series can't be told apart without a database, which
+	// the feed needs anyway to save the subscription
+	if LinkSubscription(db, sub) == nil {
+		existing, _ := GetChatMangaSubscription(db, sub.ChatID, sub.MangaID)
+		if existing != nil && existing.Personal {
+			return existing, shareSubscription(db, existing)
+		}
+
+		if existing != nil {
+			return existing, ErrAlreadySubscribed
+		}
+	}
+
+	err := ClearRemovedSubscription(db, sub.ChatID, sub.MangaURL)
+	if err != nil {
+		return nil, err
+	}
+
+	err = feed.Subscribe(sub)
+	if err != nil {
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+// shareSubscription turns a personal subscription of a group
+// chat into one shared by the whole group, keeping its followers.
+func shareSubscription(db *models.DatabaseConfig, sub *models.Subscription) error {
+	_, err := db.MongoClient.Collection("subscription").UpdateOne(
+		db.Ctx,
+		bson.M{"_id": sub.ID},
+		bson.M{"$set": bson.M{"personal": false}},
+	)
+	if err != nil {
+		log.Println("There was an error sharing the subscription: ", err)
+		return err
+	}
+
+	sub.Personal = false
+
+	return nil
+}
+
+// MigrateSubscription method moves a subscription to the entry of its
+// manga series in another feed. If the series has no known entry in the
+// feed, the feed is searched for one of its titles. The last chapter is
+// taken from the new feed so moving doesn't send an alert. It returns
+// ErrNoFeedEntry if the series isn't found in the feed.
+func MigrateSubscription(db *models.DatabaseConfig, sub *models.Subscription, feedCode int) error {
+
+	if sub.MangaFeed == feedCode {
+		return nil
+	}
+
+	feed := NewMangaInterface(feedCode, db)
+	if feed == nil {
+		log.Println("Unknown manga feed: ", feedCode)
+		return errors.New("unknown manga feed")
+	}
+
+	if sub.MangaID.IsZero() {
+		err := LinkSubscription(db, sub)
+		if err != nil {
+			return err
+		}
+	}
+
+	manga, err := GetManga(db, sub.MangaID)
+	if err != nil {
+		return err
+	}
+
+	SetFeedLanguages(feed, GetChatSettings(db, sub.ChatID).Languages)
+
+	mangaURL := MangaEntry(manga, feedCode)
+	if mangaURL == "" {
+		mangaURL = searchMangaEntry(feed, manga)
+		if mangaURL == "" {
+			return ErrNoFeedEntry
+		}
+
+		_, err = ResolveManga(db, feedCode, mangaURL, manga.Title, manga.AltTitles)
+		if err != nil {
+			return err
+		}
+	}
+
+	last := ""
+	if chapter, err := LastChapter(feed, mangaURL); err == nil && chapter != nil {
+		last = chapter.URL
+	}
+
+	_, err = db.MongoClient.Collection("subscription").UpdateOne(
+		db.Ctx,
+		bson.M{"_id": sub.ID},
+		bson.M{"$set": bson.M{
+			"mangafeed":      feedCode,
+			"mangaurl":       mangaURL,
+			"lastchapterurl": last,
+			"coverurl":       "",
+		}},
+	)
+	if err != nil {
+		log.Println("There was an error moving the subscription: ", err)
+		return err
+	}
+
+	sub.MangaFeed = feedCode
+	sub.MangaURL = mangaURL
+	sub.LastChapterURL = last
+	sub.CoverURL = ""
+
+	return nil
+}
+
+// MigrateChatSubscriptions method moves the subscriptions of a Chat to
+// another feed, see MigrateSubscription. RSS subscriptions are kept as
+// they are. It returns how many subscriptions were moved out of how
+// many could be.
+func MigrateChatSubscriptions(db *models.DatabaseConfig, chatID int64, feedCode int) (int, int, error) {
+
+	subs, err := GetChatSubscriptions(db, chatID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	moved, total := 0, 0
+	for _, sub := range subs {
+		if sub.MangaFeed == feedCode {
+			continue
+		}
+
+		if _, ok := NewMangaInterface(sub.MangaFeed, db).(*rss.RSSFeed); ok {
+			continue
+		}
+
+		total++
+
+		err := MigrateSubscription(db, sub, feedCode)
+		if err != nil {
+			if err != ErrNoFeedEntry {
+				log.Println("There was an error moving subscription: ", sub.ID.Hex(), err)
+			}
+			continue
+		}
+
+		moved++
+	}
+
+	return moved, total, nil
+}
+
+// MangaEntry function returns the URL to the entry of a manga
+// series in a feed, or an empty string if it has no known entry.
+func MangaEntry(manga *models.Manga, feedCode int) string {
+	for _, e := range manga.ExternalIDs {
+		if e.Feed == feedCode {
+			return e.URL
+		}
+	}
+
+	return ""
+}
+
+// searchMangaEntry searches a feed for the title of a manga series
+// and returns the URL to the first result that has one of the
+// series' titles, or an empty string if there's none.
+func searchMangaEntry(feed MangaFeedInterface, manga *models.Manga) string {
+	res := feed.QueryManga(manga.Title)
+	if res == nil {
+		return ""
+	}
+
+	for _, s := range res.Suggestions {
+		if contains(manga.Titles, NormalizeTitle(s.Value)) {
+			return fmt.Sprintf(feed.ViewManga(), s.Data)
+		}
+	}
+
+	return ""
+}
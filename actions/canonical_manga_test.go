@@ -0,0 +1,236 @@
+package actions
+
+import (
+	"context"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/tavomoya/mangagram/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// testSearchFeed is a manga feed whose
+// searches always return the same titles.
+type testSearchFeed struct {
+	suggestions []models.MangaSuggestions
+}
+
+func (f *testSearchFeed) QueryManga(name string) *models.ApiQuerySuggestions {
+	return &models.ApiQuerySuggestions{Suggestions: f.suggestions}
+}
+
+func (f *testSearchFeed) ViewManga() string {
+	return "https://example.com/manga/%s"
+}
+
+func (f *testSearchFeed) Subscribe(subscription *models.Subscription) error {
+	return nil
+}
+
+func (f *testSearchFeed) GetLastMangaChapter(url string) (string, error) {
+	return "", nil
+}
+
+func TestNormalizeTitle(t *testing.T) {
+	is := is.New(t)
+
+	cases := map[string]string{
+		"Kimetsu no Yaiba: Tanjirō":   "kimetsu no yaiba tanjiro",
+		"  TOKYO   GHOUL:re ":         "tokyo ghoul re",
+		"Shingeki no Kyojin (Attack)": "shingeki no kyojin attack",
+		"Pokémon Adventures #1":       "pokemon adventures 1",
+		"東京喰種":                        "東京喰種",
+		"!!!":                         "",
+	}
+
+	for title, expect := range cases {
+		is.Equal(NormalizeTitle(title), expect)
+	}
+}
+
+func TestResolveManga(t *testing.T) {
+	opts := &mtest.Options{}
+	opts.ClientType(mtest.Mock)
+	opts.CollectionName("manga")
+	opts.DatabaseName("mangagram")
+	opts.ShareClient(true)
+
+	mt := mtest.New(t, opts)
+	defer mt.Close()
+
+	is := is.New(t)
+	config := &models.DatabaseConfig{
+		Ctx:         context.Background(),
+		MongoClient: mt.Client.Database("mangagram"),
+	}
+
+	mt.Run("Nil Database", func(t *mtest.T) {
+		manga, err := ResolveManga(nil, 2, "https://manganelo.com/manga/tokyo_ghoul", "Tokyo Ghoul", nil)
+		is.True(manga == nil)
+		is.True(err != nil)
+	})
+
+	mt.Run("No manga supplied", func(t *mtest.T) {
+		manga, err := ResolveManga(config, 2, "", "Tokyo Ghoul", nil)
+		is.True(manga == nil)
+		is.True(err != nil)
+	})
+
+	mt.Run("New manga", func(t *mtest.T) {
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "manga.manga", mtest.FirstBatch),
+			mtest.CreateCursorResponse(0, "manga.manga", mtest.FirstBatch),
+			mtest.CreateSuccessResponse(),
+		)
+
+		manga, err := ResolveManga(config, 2, "https://manganelo.com/manga/tokyo_ghoul", "Tokyo Ghoul", []string{"Tokyo Kushu"})
+		is.NoErr(err)
+		is.True(!manga.ID.IsZero())
+		is.Equal(manga.Title, "Tokyo Ghoul")
+		is.Equal(manga.Titles, []string{"tokyo ghoul", "tokyo kushu"})
+		is.Equal(manga.ExternalIDs, []models.MangaExternalID{{Feed: 2, URL: "https://manganelo.com/manga/tokyo_ghoul"}})
+	})
+
+	mt.Run("Known entry", func(t *mtest.T) {
+		id := primitive.NewObjectID()
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "manga.manga", mtest.FirstBatch, bson.D{
+				{Key: "_id", Value: id},
+				{Key: "title", Value: "Tokyo Ghoul"},
+				{Key: "titles", Value: bson.A{"tokyo ghoul"}},
+				{Key: "externalids", Value: bson.A{
+					bson.D{{Key: "feed", Value: 2}, {Key: "url", Value: "https://manganelo.com/manga/tokyo_ghoul"}},
+				}},
+			}),
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 0}},
+		)
+
+		manga, err := ResolveManga(config, 2, "https://manganelo.com/manga/tokyo_ghoul", "Tokyo Ghoul", nil)
+		is.NoErr(err)
+		is.Equal(manga.ID, id)
+		is.Equal(len(manga.ExternalIDs), 1)
+	})
+
+	mt.Run("Entry saved by someone else meanwhile", func(t *mtest.T) {
+		id := primitive.NewObjectID()
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "manga.manga", mtest.FirstBatch),
+			mtest.CreateCursorResponse(0, "manga.manga", mtest.FirstBatch),
+			mtest.CreateWriteErrorsResponse(mtest.WriteError{Code: 11000, Message: "duplicate key"}),
+			mtest.CreateCursorResponse(0, "manga.manga", mtest.FirstBatch, bson.D{
+				{Key: "_id", Value: id},
+				{Key: "title", Value: "Tokyo Ghoul"},
+			}),
+		)
+
+		manga, err := ResolveManga(config, 2, "https://manganelo.com/manga/tokyo_ghoul", "Tokyo Ghoul", nil)
+		is.NoErr(err)
+		is.Equal(manga.ID, id)
+	})
+
+	mt.Run("Same manga in another feed", func(t *mtest.T) {
+		id := primitive.NewObjectID()
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "manga.manga", mtest.FirstBatch),
+			mtest.CreateCursorResponse(0, "manga.manga", mtest.FirstBatch, bson.D{
+				{Key: "_id", Value: id},
+				{Key: "title", Value: "Tokyo Ghoul"},
+				{Key: "titles", Value: bson.A{"tokyo ghoul"}},
+				{Key: "externalids", Value: bson.A{
+					bson.D{{Key: "feed", Value: 2}, {Key: "url", Value: "https://manganelo.com/manga/tokyo_ghoul"}},
+				}},
+			}),
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}},
+		)
+
+		manga, err := ResolveManga(config, 5, "https://mangadex.org/title/6a1d1cb1", "TOKYO GHOUL", []string{"東京喰種"})
+		is.NoErr(err)
+		is.Equal(manga.ID, id)
+		is.Equal(manga.AltTitles, []string{"TOKYO GHOUL", "東京喰種"})
+		is.Equal(manga.Titles, []string{"tokyo ghoul", "東京喰種"})
+		is.Equal(MangaEntry(manga, 5), "https://mangadex.org/title/6a1d1cb1")
+		is.Equal(MangaEntry(manga, 2), "https://manganelo.com/manga/tokyo_ghoul")
+	})
+
+	mt.Run("Failed to query", func(t *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCommandErrorResponse(mtest.CommandError{
+			Code: 90,
+		}))
+
+		manga, err := ResolveManga(config, 2, "https://manganelo.com/manga/tokyo_ghoul", "Tokyo Ghoul", nil)
+		is.True(manga == nil)
+		is.True(err != nil)
+	})
+}
+
+func TestGetChatMangaSubscription(t *testing.T) {
+	opts := &mtest.Options{}
+	opts.ClientType(mtest.Mock)
+	opts.CollectionName("subscription")
+	opts.DatabaseName("mangagram")
+	opts.ShareClient(true)
+
+	mt := mtest.New(t, opts)
+	defer mt.Close()
+
+	is := is.New(t)
+	config := &models.DatabaseConfig{
+		Ctx:         context.Background(),
+		MongoClient: mt.Client.Database("mangagram"),
+	}
+
+	mt.Run("Not subscribed", func(t *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "subscription.subscription", mtest.FirstBatch))
+
+		sub, err := GetChatMangaSubscription(config, 1, primitive.NewObjectID())
+		is.NoErr(err)
+		is.True(sub == nil)
+	})
+
+	mt.Run("Subscribed in another feed", func(t *mtest.T) {
+		mangaID := primitive.NewObjectID()
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "subscription.subscription", mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: primitive.NewObjectID()},
+			{Key: "chatid", Value: int64(1)},
+			{Key: "manganame", Value: "Tokyo Ghoul"},
+			{Key: "mangafeed", Value: 2},
+			{Key: "mangaid", Value: mangaID},
+		}))
+
+		sub, err := GetChatMangaSubscription(config, 1, mangaID)
+		is.NoErr(err)
+		is.Equal(sub.MangaID, mangaID)
+		is.Equal(sub.MangaFeed, 2)
+	})
+}
+
+func TestSearchMangaEntry(t *testing.T) {
+	is := is.New(t)
+	manga := &models.Manga{
+		Title:  "Attack on Titan",
+		Titles: []string{"attack on titan", "shingeki no kyojin"},
+	}
+
+	feed := &testSearchFeed{suggestions: []models.MangaSuggestions{
+		{Data: "attack-on-titan-junior-high", Value: "Attack on Titan: Junior High"},
+		{Data: "shingeki-no-kyojin", Value: "Shingeki no Kyojin"},
+	}}
+	is.Equal(searchMangaEntry(feed, manga), "https://example.com/manga/shingeki-no-kyojin")
+
+	feed.suggestions = feed.suggestions[:1]
+	is.Equal(searchMangaEntry(feed, manga), "")
+}
+
+func TestMigrateSubscription(t *testing.T) {
+	is := is.New(t)
+
+	sub := &models.Subscription{MangaFeed: 2, MangaURL: "https://manganelo.com/manga/tokyo_ghoul"}
+	err := MigrateSubscription(nil, sub, 2)
+	is.NoErr(err)
+	is.Equal(sub.MangaURL, "https://manganelo.com/manga/tokyo_ghoul")
+
+	err = MigrateSubscription(nil, sub, 99)
+	is.True(err != nil)
+}
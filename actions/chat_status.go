@@ -0,0 +1,130 @@
+package actions
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/tavomoya/mangagram/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// chatGoneErrors are parts of the descriptions of the errors
+// Telegram returns when the bot can't reach a chat anymore.
+var chatGoneErrors = []string{
+	"bot was blocked by the user",
+	"chat not found",
+	"bot was kicked",
+	"user is deactivated",
+	"group chat was deleted",
+	"bot is not a member",
+}
+
+// activeChats holds the chats known to have no paused subscriptions,
+// so ReactivateChat doesn't query them on every update they send.
+var activeChats = struct {
+	sync.Mutex
+	m map[int64]bool
+}{m: map[int64]bool{}}
+
+// setChatActive records whether a chat is known
+// to have no paused subscriptions.
+func setChatActive(chatID int64, active bool) {
+	activeChats.Lock()
+	defer activeChats.Unlock()
+
+	if active {
+		activeChats.m[chatID] = true
+	} else {
+		delete(activeChats.m, chatID)
+	}
+}
+
+// IsChatGone function reports whether err means the bot can't
+// send messages to a chat anymore, like when a user blocks the
+// bot, the bot is kicked from a group or the chat is deleted.
+func IsChatGone(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	desc := strings.ToLower(err.Error())
+	for _, e := range chatGoneErrors {
+		if strings.Contains(desc, e) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DeactivateChat method pauses the subscriptions of a Chat the bot
+// can't reach anymore, so they aren't polled for new chapters, and
+// marks the chat's pending alerts in the outbox as failed.
+func DeactivateChat(db *models.DatabaseConfig, chatID int64, reason string) error {
+
+	if db == nil {
+		log.Println("The DB model is nil")
+		return errors.New("the DB model passed is nil, can't operate")
+	}
+
+	setChatActive(chatID, false)
+
+	_, err := db.MongoClient.Collection("subscription").UpdateMany(
+		db.Ctx,
+		bson.M{"chatid": chatID},
+		bson.M{"$set": bson.M{"inactive": true}},
+	)
+	if err != nil {
+		log.Println("There was an error deactivating the chat's subscriptions: ", err)
+		return err
+	}
+
+	_, err = db.MongoClient.Collection("outbox").UpdateMany(
+		db.Ctx,
+		bson.M{"chatid": chatID, "status": AlertPending},
+		bson.M{"$set": bson.M{"status": AlertFailed, "lasterror": reason}},
+	)
+	if err != nil {
+		log.Println("There was an error failing the chat's alerts: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// ReactivateChat method resumes the subscriptions of a Chat
+// that were paused by DeactivateChat. It returns true if
+// the chat had any paused subscriptions. Chats already
+// checked since they were last paused aren't queried.
+func ReactivateChat(db *models.DatabaseConfig, chatID int64) (bool, error) {
+
+	if db == nil {
+		log.Println("The DB model is nil")
+		return false, errors.New("the DB model passed is nil, can't operate")
+	}
+
+	activeChats.Lock()
+	active := activeChats.m[chatID]
+	activeChats.Unlock()
+
+	if active {
+		return false, nil
+	}
+
+	res, err := db.MongoClient.Collection("subscription").UpdateMany(
+		db.Ctx,
+		bson.M{"chatid": chatID, "inactive": true},
+		bson.M{"$set": bson.M{"inactive": false}},
+	)
+	if err != nil {
+		log.Println("There was an error reactivating the chat's subscriptions: ", err)
+		return false, err
+	}
+
+	setChatActive(chatID, true)
+
+	return res.ModifiedCount > 0, nil
+}
@@ -0,0 +1,132 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/tavomoya/mangagram/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestIsChatGone(t *testing.T) {
+	is := is.New(t)
+
+	is.True(IsChatGone(errors.New("api error: Forbidden: bot was blocked by the user")))
+	is.True(IsChatGone(errors.New("api error: Bad Request: chat not found")))
+	is.True(IsChatGone(errors.New("api error: Forbidden: bot was kicked from the supergroup chat")))
+	is.True(IsChatGone(errors.New("api error: Forbidden: user is deactivated")))
+	is.True(!IsChatGone(errors.New("api error: Too Many Requests: retry after 5")))
+	is.True(!IsChatGone(nil))
+}
+
+func TestDeactivateChat(t *testing.T) {
+	opts := &mtest.Options{}
+	opts.ClientType(mtest.Mock)
+	opts.CollectionName("subscription")
+	opts.DatabaseName("mangagram")
+	opts.ShareClient(true)
+
+	mt := mtest.New(t, opts)
+	defer mt.Close()
+
+	is := is.New(t)
+	config := &models.DatabaseConfig{
+		Ctx:         context.Background(),
+		MongoClient: mt.Client.Database("mangagram"),
+	}
+
+	mt.Run("Nil Database", func(t *mtest.T) {
+		err := DeactivateChat(nil, 1, "chat not found")
+		is.True(err != nil)
+	})
+
+	mt.Run("Failed to update", func(t *mtest.T) {
+		mt.AddMockResponses(mtest.CreateWriteErrorsResponse(mtest.WriteError{
+			Code:    11000,
+			Message: "something went wrong",
+		}))
+
+		err := DeactivateChat(config, 1, "chat not found")
+		is.True(err != nil)
+	})
+
+	mt.Run("Success", func(t *mtest.T) {
+		mt.AddMockResponses(
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 2}, {Key: "nModified", Value: 2}},
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}},
+		)
+
+		err := DeactivateChat(config, 1, "chat not found")
+		is.NoErr(err)
+	})
+}
+
+func TestReactivateChat(t *testing.T) {
+	opts := &mtest.Options{}
+	opts.ClientType(mtest.Mock)
+	opts.CollectionName("subscription")
+	opts.DatabaseName("mangagram")
+	opts.ShareClient(true)
+
+	mt := mtest.New(t, opts)
+	defer mt.Close()
+
+	is := is.New(t)
+	config := &models.DatabaseConfig{
+		Ctx:         context.Background(),
+		MongoClient: mt.Client.Database("mangagram"),
+	}
+
+	mt.Run("Nil Database", func(t *mtest.T) {
+		_, err := ReactivateChat(nil, 1)
+		is.True(err != nil)
+	})
+
+	mt.Run("Failed to update", func(t *mtest.T) {
+		mt.AddMockResponses(mtest.CreateWriteErrorsResponse(mtest.WriteError{
+			Code:    11000,
+			Message: "something went wrong",
+		}))
+
+		_, err := ReactivateChat(config, 1)
+		is.True(err != nil)
+	})
+
+	mt.Run("Nothing to reactivate", func(t *mtest.T) {
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 0}, {Key: "nModified", Value: 0}})
+
+		ok, err := ReactivateChat(config, 1)
+		is.NoErr(err)
+		is.True(!ok)
+
+		// The chat isn't queried again
+		ok, err = ReactivateChat(config, 1)
+		is.NoErr(err)
+		is.True(!ok)
+	})
+
+	mt.Run("Reactivated", func(t *mtest.T) {
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 2}, {Key: "nModified", Value: 2}})
+
+		ok, err := ReactivateChat(config, 2)
+		is.NoErr(err)
+		is.True(ok)
+	})
+
+	mt.Run("Paused again", func(t *mtest.T) {
+		mt.AddMockResponses(
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 2}, {Key: "nModified", Value: 2}},
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 0}, {Key: "nModified", Value: 0}},
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 2}, {Key: "nModified", Value: 2}},
+		)
+
+		is.NoErr(DeactivateChat(config, 2, "bot was blocked by the user"))
+
+		ok, err := ReactivateChat(config, 2)
+		is.NoErr(err)
+		is.True(ok)
+	})
+}
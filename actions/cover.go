@@ -0,0 +1,81 @@
+package actions
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// coverSelectors are the elements that hold the cover
+// of a title in its page, in order of preference.
+var coverSelectors = []struct {
+	Selector string
+	Attr     string
+}{
+	{`meta[property="og:image"]`, "content"},
+	{`meta[name="twitter:image"]`, "content"},
+	{`link[rel="image_src"]`, "href"},
+}
+
+// PageCover function loads the page of a manga title and returns
+// the URL to its cover image, taken from the page's Open Graph or
+// Twitter Card metadata. It returns an empty string if the page
+// has none, and an error if the page cannot be loaded.
+func PageCover(pageURL string) (string, error) {
+	if pageURL == "" {
+		return "", nil
+	}
+
+	page, err := loadPage(pageURL)
+	if err != nil {
+		return "", err
+	}
+
+	return pageCover(page, pageURL), nil
+}
+
+// pageCover returns the URL to the cover image in a page's
+// metadata, or an empty string if the page has none.
+func pageCover(page *goquery.Document, pageURL string) string {
+	for _, c := range coverSelectors {
+		cover, ok := page.Find(c.Selector).First().Attr(c.Attr)
+		cover = strings.TrimSpace(cover)
+		if !ok || cover == "" {
+			continue
+		}
+
+		// Relative URLs are resolved against the page
+		base, err := url.Parse(pageURL)
+		if err != nil {
+			return cover
+		}
+
+		ref, err := url.Parse(cover)
+		if err != nil {
+			return ""
+		}
+
+		return base.ResolveReference(ref).String()
+	}
+
+	return ""
+}
+
+// loadPage loads and parses an HTML page.
+func loadPage(pageURL string) (*goquery.Document, error) {
+	res, err := http.Get(pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status code was not OK: %d", res.StatusCode)
+	}
+
+	return goquery.NewDocumentFromReader(res.Body)
+}
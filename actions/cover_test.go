@@ -0,0 +1,64 @@
+package actions
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/tavomoya/mangagram/actions/rss"
+)
+
+func testCoverServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/og":
+			rw.Write([]byte(`<html><head><meta property="og:image" content="/covers/one-piece.jpg"></head></html>`))
+		case "/twitter":
+			rw.Write([]byte(`<html><head><meta name="twitter:image" content="https://cdn.example.com/naruto.png"></head></html>`))
+		case "/none":
+			rw.Write([]byte(`<html><head><title>No cover</title></head></html>`))
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestPageCover(t *testing.T) {
+	is := is.New(t)
+	server := testCoverServer()
+	defer server.Close()
+
+	t.Run("Open Graph image", func(t *testing.T) {
+		cover, err := PageCover(server.URL + "/og")
+		is.NoErr(err)
+		is.Equal(cover, server.URL+"/covers/one-piece.jpg")
+	})
+
+	t.Run("Twitter Card image", func(t *testing.T) {
+		cover, err := PageCover(server.URL + "/twitter")
+		is.NoErr(err)
+		is.Equal(cover, "https://cdn.example.com/naruto.png")
+	})
+
+	t.Run("No image", func(t *testing.T) {
+		cover, err := PageCover(server.URL + "/none")
+		is.NoErr(err)
+		is.Equal(cover, "")
+	})
+
+	t.Run("Page not found", func(t *testing.T) {
+		_, err := PageCover(server.URL + "/missing")
+		is.True(err != nil)
+	})
+}
+
+func TestMangaCover(t *testing.T) {
+	is := is.New(t)
+	server := testCoverServer()
+	defer server.Close()
+
+	is.Equal(MangaCover(NewMangaInterface(7, nil), server.URL+"/og"), server.URL+"/covers/one-piece.jpg")
+	is.Equal(MangaCover(NewMangaInterface(7, nil), server.URL+"/missing"), "")
+	is.Equal(MangaCover(rss.NewRSSFeed(nil), server.URL+"/og"), "")
+}
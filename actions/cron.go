@@ -5,7 +5,10 @@ import (
 	"log"
 	"strconv"
 	"time"
+	"unicode/utf8"
 
+	"github.com/tavomoya/mangagram/actions/i18n"
+	"github.com/tavomoya/mangagram/actions/rss"
 	"github.com/tavomoya/mangagram/models"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -14,16 +17,22 @@ import (
 
 // GetMangaUpdates function runs a goroutine every 6h.
 // The goroutine queries the subscription collection and looks
-// for new chapters. If a new chapter is found, a message
-// is sent to the Chat that got subscribed to the title.
-func GetMangaUpdates(job *models.Job, bot *tb.Bot) {
+// for new chapters. If a new chapter is found, an alert for
+// the Chat that got subscribed to the title is written to the
+// outbox, to be sent by DeliverQueuedAlerts. The titles' cached
+// info, which /unread counts chapters from, is kept up to date.
+func GetMangaUpdates(job *models.Job) {
 	jobName := "GetMangaUpdates"
 
 	for t := range time.NewTicker(6 * time.Hour).C {
 		log.Println("Running Manga Updates Goroutine...", t)
 		go func() {
 			started := time.Now()
-			cursor, err := job.DB.MongoClient.Collection("subscription").Find(job.DB.Ctx, bson.M{})
+
+			// Removed subscriptions that can't be restored anymore are deleted
+			PurgeRemovedSubscriptions(job.DB)
+
+			cursor, err := job.DB.MongoClient.Collection("subscription").Find(job.DB.Ctx, bson.M{"inactive": bson.M{"$ne": true}, "deleted_at": bson.M{"$exists": false}})
 			if err != nil {
 				onError(jobName, started, err)
 			}
@@ -35,30 +44,252 @@ func GetMangaUpdates(job *models.Job, bot *tb.Bot) {
 				onError(jobName, started, err)
 			}
 
+			// Chats have many subscriptions, their settings are read once per run
+			chats := map[int64]*models.ChatSettings{}
+			chatSettings := func(chatID int64) *models.ChatSettings {
+				if _, ok := chats[chatID]; !ok {
+					chats[chatID] = GetChatSettings(job.DB, chatID)
+				}
+
+				return chats[chatID]
+			}
+
 			for _, manga := range subs {
 				feed := NewMangaInterface(manga.MangaFeed, job.DB)
 				if feed == nil {
 					continue
 				}
 
+				_, isRSS := feed.(*rss.RSSFeed)
+
+				// Subscriptions created before manga series
+				// existed are linked to theirs the first time
+				if !isRSS && manga.MangaID.IsZero() {
+					LinkSubscription(job.DB, manga)
+				}
+
+				// The chat might have changed its languages since subscribing
+				settings := chatSettings(manga.ChatID)
+				SetFeedLanguages(feed, settings.Languages)
+
 				// Get the last chapter for each manga
-				last, err := feed.GetLastMangaChapter(manga.MangaURL)
-				if err != nil || last == "" || last == fmt.Sprintf(feed.ViewManga(), "") {
+				chapter, err := LastChapter(feed, manga.MangaURL)
+				if err != nil || chapter == nil || chapter.URL == "" || chapter.URL == fmt.Sprintf(feed.ViewManga(), "") {
 					continue // LAter will decide what to do here
 				}
 
+				last := chapter.URL
+
 				if manga.LastChapterURL == "" || last != manga.LastChapterURL {
+					if manga.CoverURL == "" {
+						manga.CoverURL = MangaCover(feed, manga.MangaURL)
+					}
+
+					alert := &models.QueuedAlert{
+						ChatID:         manga.ChatID,
+						SubscriptionID: manga.ID,
+						MangaName:      manga.MangaName,
+						MangaURL:       manga.MangaURL,
+						MangaFeed:      manga.MangaFeed,
+						CoverURL:       manga.CoverURL,
+						ChapterURL:     last,
+						ChapterNumber:  chapter.Number,
+						ChapterTitle:   chapter.Title,
+						Followers:      manga.Followers,
+					}
+
+					switch now := time.Now(); {
+					case settings.Delivery != "instant":
+						alert.DeliverAt = NextDigest(settings, now)
+						alert.Digest = true
+					case InQuietHours(settings, now):
+						alert.DeliverAt = QuietHoursEnd(settings, now)
+					}
+
+					// Keep the last chapter if the alert can't be queued,
+					// so the chapter is found again in the next run
+					if QueueAlert(job.DB, alert) != nil {
+						continue
+					}
+
 					manga.LastChapterURL = last
-					msg := fmt.Sprintf("Here is a new chapter for %s\n %s", manga.MangaName, last)
-					to, _ := bot.ChatByID(strconv.FormatInt(manga.ChatID, 10))
-					bot.Send(to, msg)
 					updateLastChapter(manga, job)
+
+					// The new chapter counts as unread in /unread
+					if !isRSS {
+						_, err = RefreshMangaInfo(job.DB, manga.MangaFeed, manga.MangaURL)
+					}
+				} else if !isRSS {
+					// Titles nobody looked at get their info cached too
+					_, err = GetMangaInfo(job.DB, manga.MangaFeed, manga.MangaURL)
+				}
+
+				if err != nil {
+					log.Println("There was an error updating the manga info: ", err)
 				}
 			}
 		}()
 	}
 }
 
+// DeliverQueuedAlerts function runs a goroutine every minute.
+// The goroutine sends the alerts in the outbox that are due and
+// marks them as delivered. Digest alerts are sent in a single
+// summary per chat. Alerts that can't be sent are retried later,
+// and when Telegram asks the bot to slow down the run stops. Chats
+// the bot can't reach anymore get their subscriptions deactivated.
+func DeliverQueuedAlerts(job *models.Job, bot *tb.Bot) {
+	jobName := "DeliverQueuedAlerts"
+
+	for range time.NewTicker(time.Minute).C {
+		started := time.Now()
+
+		alerts, err := GetDueAlerts(job.DB, started)
+		if err != nil {
+			onError(jobName, started, err)
+			continue
+		}
+
+		// Chats the bot can't reach anymore
+		gone := map[int64]bool{}
+
+		// failed reschedules alerts that couldn't be sent, or deactivates
+		// their chat if the bot can't reach it anymore. It returns true
+		// if the bot hit a Telegram flood limit.
+		failed := func(chatID int64, list []*models.QueuedAlert, err error) bool {
+			log.Println("There was an error delivering an alert: ", err)
+
+			if IsChatGone(err) {
+				gone[chatID] = true
+				DeactivateChat(job.DB, chatID, err.Error())
+				return false
+			}
+
+			for _, alert := range list {
+				RetryAlert(job.DB, alert, err, started)
+			}
+
+			_, flood := RetryAfter(err)
+			return flood
+		}
+
+		digests := map[int64][]*models.QueuedAlert{}
+		stopped := false
+
+		for _, alert := range alerts {
+			if gone[alert.ChatID] {
+				continue
+			}
+
+			settings := GetChatSettings(job.DB, alert.ChatID)
+
+			// The chat may have changed its quiet hours since it was queued
+			if InQuietHours(settings, started) {
+				continue
+			}
+
+			if alert.Digest {
+				digests[alert.ChatID] = append(digests[alert.ChatID], alert)
+				continue
+			}
+
+			err := sendChapterAlert(bot, settings, alert)
+			if err != nil {
+				if stopped = failed(alert.ChatID, []*models.QueuedAlert{alert}, err); stopped {
+					break
+				}
+				continue
+			}
+
+			MarkAlertDelivered(job.DB, alert.ID)
+		}
+
+		for chatID, list := range digests {
+			if stopped {
+				break
+			}
+
+			if gone[chatID] {
+				continue
+			}
+
+			settings := GetChatSettings(job.DB, chatID)
+
+			for _, msg := range digestMessages(settings, list) {
+				err := sendAlert(bot, chatID, msg.Text, tb.ModeHTML, tb.NoPreview)
+				if err != nil {
+					stopped = failed(chatID, msg.Alerts, err)
+					break
+				}
+
+				for _, alert := range msg.Alerts {
+					MarkAlertDelivered(job.DB, alert.ID)
+				}
+			}
+		}
+	}
+}
+
+// sendChapterAlert sends a new chapter alert to its chat, with the
+// alert buttons. Alerts with a cover are sent as a photo captioned
+// with the alert, or as text if the photo can't be sent.
+func sendChapterAlert(bot *tb.Bot, settings *models.ChatSettings, alert *models.QueuedAlert) error {
+	msg, opts := chapterAlert(settings, alert)
+
+	if alert.CoverURL != "" && utf8.RuneCountInString(msg) <= maxCaptionLength {
+		photo := &tb.Photo{File: tb.FromURL(alert.CoverURL), Caption: msg}
+
+		// The markup is built for every message because
+		// telebot changes its buttons when sending it
+		err := sendAlert(bot, alert.ChatID, photo, append(opts, alertMarkup(settings, alert))...)
+		if _, flood := RetryAfter(err); err == nil || flood || IsChatGone(err) {
+			return err
+		}
+
+		log.Println("There was an error sending the alert with its cover, sending it as text: ", err)
+	}
+
+	return sendAlert(bot, alert.ChatID, msg, append(opts, alertMarkup(settings, alert))...)
+}
+
+// sendAlert sends an alert message to a chat.
+func sendAlert(bot *tb.Bot, chatID int64, msg interface{}, opts ...interface{}) error {
+	to, err := bot.ChatByID(strconv.FormatInt(chatID, 10))
+	if err != nil {
+		return err
+	}
+
+	_, err = bot.Send(to, msg, opts...)
+	return err
+}
+
+// chapterAlert returns the new chapter alert and the options
+// to send it with, built with the chat's alert template. The
+// default template is used if the chat's template fails.
+func chapterAlert(settings *models.ChatSettings, alert *models.QueuedAlert) (string, []interface{}) {
+	data := NewAlertData(alert)
+
+	text, mode := alertTemplate(settings)
+	msg, err := RenderAlert(text, mode, data)
+	if err != nil {
+		log.Println("There was an error rendering the chat's alert template: ", err)
+		mode = TemplateText
+		msg, _ = RenderAlert(i18n.T(settings.Locale, "alert.template_default"), mode, data)
+	}
+
+	// Group members who follow the title get notified
+	if mentions := Mentions(mode, alert.Followers); mentions != "" {
+		msg += "\n\n" + mentions
+	}
+
+	opts := []interface{}{TemplateParseMode(mode)}
+	if settings.DisablePreview {
+		opts = append(opts, tb.NoPreview)
+	}
+
+	return msg, opts
+}
+
 func onError(name string, started time.Time, err error) {
 	ended := time.Now()
 	fmt.Printf("*** [*] Goroutine '%s' finished unexpectedly ***", name)
@@ -74,12 +305,18 @@ func onSuccess(name string, started time.Time) {
 	fmt.Printf("*** [*] Goroutine '%s' time elapsed: %v ***\n", name, ended.Sub(started))
 }
 
+// updateLastChapter saves the last chapter and cover found for a
+// subscription. Only those are set, members might have followed it or
+// removed it while the feed was checked.
 func updateLastChapter(manga *models.Subscription, job *models.Job) {
 	_, err := job.DB.MongoClient.Collection("subscription").UpdateOne(
 		job.DB.Ctx,
 		bson.M{"_id": manga.ID},
 		bson.M{
-			"$set": manga,
+			"$set": bson.M{
+				"lastchapterurl": manga.LastChapterURL,
+				"coverurl":       manga.CoverURL,
+			},
 		},
 	)
 	if err != nil {
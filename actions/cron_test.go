@@ -0,0 +1,57 @@
+package actions
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/tavomoya/mangagram/models"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+func TestChapterAlert(t *testing.T) {
+	is := is.New(t)
+
+	alert := &models.QueuedAlert{
+		MangaName:     "One Piece",
+		MangaFeed:     5,
+		ChapterURL:    "https://example.com/1000",
+		ChapterNumber: "1000",
+	}
+
+	settings := DefaultChatSettings(1)
+	msg, opts := chapterAlert(settings, alert)
+	is.Equal(msg, "Here is a new chapter for One Piece\n https://example.com/1000")
+	is.Equal(opts, []interface{}{tb.ModeDefault})
+
+	settings.Format = "compact"
+	settings.DisablePreview = true
+	msg, opts = chapterAlert(settings, alert)
+	is.Equal(msg, "One Piece: https://example.com/1000")
+	is.Equal(opts, []interface{}{tb.ModeDefault, tb.NoPreview})
+
+	settings.Format = "rich"
+	settings.DisablePreview = false
+	msg, opts = chapterAlert(settings, alert)
+	is.Equal(msg, "📖 <b>One Piece</b> · Chapter 1000\n<a href=\"https://example.com/1000\">Read on Mangadex</a>")
+	is.Equal(opts, []interface{}{tb.ModeHTML})
+
+	settings.Format = "custom"
+	settings.Template = "*{{.MangaName}}* {{.Chapter}}"
+	settings.TemplateMode = TemplateMarkdown
+	msg, opts = chapterAlert(settings, alert)
+	is.Equal(msg, "*One Piece* 1000")
+	is.Equal(opts, []interface{}{tb.ParseMode("MarkdownV2")})
+
+	// Broken templates fall back to the default one
+	settings.Template = "{{.Nope}}"
+	msg, opts = chapterAlert(settings, alert)
+	is.Equal(msg, "Here is a new chapter for One Piece\n https://example.com/1000")
+	is.Equal(opts, []interface{}{tb.ModeDefault})
+
+	// Group members who follow the title are mentioned
+	settings.Format = "rich"
+	alert.Followers = []models.Follower{{UserID: 1, UserName: "luffy"}}
+	msg, _ = chapterAlert(settings, alert)
+	is.True(strings.HasSuffix(msg, "</a>\n\n@luffy"))
+}
@@ -0,0 +1,71 @@
+package actions
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// subscribePrefix is the prefix of the /start
+// payloads that subscribe a chat to a manga.
+const subscribePrefix = "sub_"
+
+// ErrInvalidPayload is returned when a /start
+// payload isn't one the bot generated.
+var ErrInvalidPayload = errors.New("invalid deep link payload")
+
+// SubscribePayload function returns the /start payload of the deep link
+// that subscribes a chat to a manga series in a feed. Payloads can only
+// have 64 letters, numbers, '_' or '-', so the series is referred to by
+// its ID instead of its URL.
+func SubscribePayload(feedCode int, mangaID primitive.ObjectID) string {
+	return fmt.Sprintf("%s%d_%s", subscribePrefix, feedCode, mangaID.Hex())
+}
+
+// DeepLink function returns the t.me link that opens a private
+// chat with a bot and sends it /start with the payload.
+func DeepLink(botName, payload string) string {
+	return fmt.Sprintf("https://t.me/%s?start=%s", botName, payload)
+}
+
+// IsSubscribePayload function reports whether a /start
+// payload is one of a subscribe deep link.
+func IsSubscribePayload(payload string) bool {
+	return strings.HasPrefix(payload, subscribePrefix)
+}
+
+// ParseSubscribePayload function returns the feed code and the manga
+// series ID of a subscribe deep link's payload, see SubscribePayload.
+// It returns ErrInvalidPayload if the payload is not well formed.
+func ParseSubscribePayload(payload string) (int, primitive.ObjectID, error) {
+	if !IsSubscribePayload(payload) {
+		return 0, primitive.NilObjectID, ErrInvalidPayload
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(payload, subscribePrefix), "_", 2)
+	if len(parts) != 2 {
+		return 0, primitive.NilObjectID, ErrInvalidPayload
+	}
+
+	feedCode, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, primitive.NilObjectID, ErrInvalidPayload
+	}
+
+	mangaID, err := primitive.ObjectIDFromHex(parts[1])
+	if err != nil {
+		return 0, primitive.NilObjectID, ErrInvalidPayload
+	}
+
+	return feedCode, mangaID, nil
+}
+
+// ShareLink function returns the link that opens Telegram's
+// dialog to share a link with a text in any chat.
+func ShareLink(link, text string) string {
+	return "https://t.me/share/url?url=" + url.QueryEscape(link) + "&text=" + url.QueryEscape(text)
+}
@@ -0,0 +1,42 @@
+package actions
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestSubscribePayload(t *testing.T) {
+	is := is.New(t)
+
+	id, _ := primitive.ObjectIDFromHex("5f8d0d55b54764421b7156c9")
+	payload := SubscribePayload(5, id)
+
+	is.Equal(payload, "sub_5_5f8d0d55b54764421b7156c9")
+	is.True(len(payload) <= 64)
+	is.Equal(DeepLink("MangaGramBot", payload), "https://t.me/MangaGramBot?start=sub_5_5f8d0d55b54764421b7156c9")
+}
+
+func TestParseSubscribePayload(t *testing.T) {
+	is := is.New(t)
+
+	id := primitive.NewObjectID()
+	feedCode, mangaID, err := ParseSubscribePayload(SubscribePayload(102, id))
+	is.NoErr(err)
+	is.Equal(feedCode, 102)
+	is.Equal(mangaID, id)
+
+	invalid := []string{"", "inline", "sub_", "sub_5", "sub_x_" + id.Hex(), "sub_5_nothex"}
+	for _, payload := range invalid {
+		_, _, err := ParseSubscribePayload(payload)
+		is.Equal(err, ErrInvalidPayload)
+	}
+}
+
+func TestShareLink(t *testing.T) {
+	is := is.New(t)
+
+	link := ShareLink("https://t.me/MangaGramBot?start=sub_5_1", "One Piece & more")
+	is.Equal(link, "https://t.me/share/url?url=https%3A%2F%2Ft.me%2FMangaGramBot%3Fstart%3Dsub_5_1&text=One+Piece+%26+more")
+}
@@ -0,0 +1,60 @@
+package actions
+
+import (
+	"html"
+	"strings"
+	"time"
+
+	"github.com/tavomoya/mangagram/actions/i18n"
+	"github.com/tavomoya/mangagram/models"
+)
+
+// maxMessageLength is the maximum length
+// of a Telegram text message.
+const maxMessageLength = 4096
+
+// NextDigest function returns the first time after t a chat's
+// digest must be sent at, in the chat's timezone. Daily digests
+// are sent every day at DigestHour, weekly ones on DigestDay.
+func NextDigest(settings *models.ChatSettings, t time.Time) time.Time {
+	local := t.In(chatLocation(settings))
+
+	next := time.Date(local.Year(), local.Month(), local.Day(), settings.DigestHour, 0, 0, 0, local.Location())
+	for !next.After(local) || (settings.Delivery == "weekly" && next.Weekday() != settings.DigestDay) {
+		next = time.Date(next.Year(), next.Month(), next.Day()+1, settings.DigestHour, 0, 0, 0, local.Location())
+	}
+
+	return next
+}
+
+// digestMessage is a single message of a digest,
+// with the alerts it includes.
+type digestMessage struct {
+	Text   string
+	Alerts []*models.QueuedAlert
+}
+
+// digestMessages returns the digest of a chat's queued alerts
+// as HTML messages, split so none of them is too long to send.
+func digestMessages(settings *models.ChatSettings, alerts []*models.QueuedAlert) []digestMessage {
+	title := i18n.T(settings.Locale, "digest.title")
+
+	msgs := []digestMessage{}
+	msg := digestMessage{Text: title}
+	for _, a := range alerts {
+		item := i18n.T(settings.Locale, "digest.item", html.EscapeString(a.MangaName), html.EscapeString(a.ChapterURL))
+		if mentions := Mentions(TemplateHTML, a.Followers); mentions != "" {
+			item += " " + mentions
+		}
+
+		if len(msg.Text)+len(item)+1 > maxMessageLength && len(msg.Alerts) > 0 {
+			msgs = append(msgs, msg)
+			msg = digestMessage{Text: title}
+		}
+
+		msg.Text = strings.Join([]string{msg.Text, item}, "\n")
+		msg.Alerts = append(msg.Alerts, a)
+	}
+
+	return append(msgs, msg)
+}
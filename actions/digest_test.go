@@ -0,0 +1,69 @@
+package actions
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/tavomoya/mangagram/models"
+)
+
+func TestNextDigest(t *testing.T) {
+	is := is.New(t)
+
+	settings := DefaultChatSettings(1)
+	settings.Delivery = "daily"
+	settings.DigestHour = 9
+
+	// Monday, May 10th 2021
+	next := NextDigest(settings, time.Date(2021, 5, 10, 8, 30, 0, 0, time.UTC))
+	is.True(next.Equal(time.Date(2021, 5, 10, 9, 0, 0, 0, time.UTC)))
+
+	next = NextDigest(settings, time.Date(2021, 5, 10, 9, 0, 0, 0, time.UTC))
+	is.True(next.Equal(time.Date(2021, 5, 11, 9, 0, 0, 0, time.UTC)))
+
+	settings.Delivery = "weekly"
+	settings.DigestDay = time.Sunday
+	next = NextDigest(settings, time.Date(2021, 5, 10, 8, 30, 0, 0, time.UTC))
+	is.True(next.Equal(time.Date(2021, 5, 16, 9, 0, 0, 0, time.UTC)))
+
+	settings.DigestDay = time.Monday
+	next = NextDigest(settings, time.Date(2021, 5, 10, 10, 0, 0, 0, time.UTC))
+	is.True(next.Equal(time.Date(2021, 5, 17, 9, 0, 0, 0, time.UTC)))
+
+	// 09:00 in Santo Domingo (UTC-4)
+	settings.Delivery = "daily"
+	settings.Timezone = "America/Santo_Domingo"
+	next = NextDigest(settings, time.Date(2021, 5, 10, 12, 0, 0, 0, time.UTC))
+	is.True(next.Equal(time.Date(2021, 5, 10, 13, 0, 0, 0, time.UTC)))
+}
+
+func TestDigestMessages(t *testing.T) {
+	is := is.New(t)
+
+	settings := DefaultChatSettings(1)
+	alerts := []*models.QueuedAlert{
+		{MangaName: "One Piece", ChapterURL: "https://example.com/1000"},
+		{MangaName: "Kaguya <3", ChapterURL: "https://example.com/200"},
+	}
+
+	msgs := digestMessages(settings, alerts)
+	is.Equal(len(msgs), 1)
+	is.Equal(msgs[0].Text, "<b>New chapters</b>\n• One Piece: https://example.com/1000\n• Kaguya &lt;3: https://example.com/200")
+	is.Equal(msgs[0].Alerts, alerts)
+
+	// Long digests are split in several messages
+	alerts = []*models.QueuedAlert{}
+	for i := 0; i < 100; i++ {
+		alerts = append(alerts, &models.QueuedAlert{MangaName: strings.Repeat("a", 50), ChapterURL: "https://example.com/1"})
+	}
+
+	msgs = digestMessages(settings, alerts)
+	is.Equal(len(msgs), 2)
+	is.Equal(len(msgs[0].Alerts)+len(msgs[1].Alerts), 100)
+	for _, m := range msgs {
+		is.True(len(m.Text) <= maxMessageLength)
+		is.True(strings.HasPrefix(m.Text, "<b>New chapters</b>\n"))
+	}
+}
@@ -0,0 +1,265 @@
+package actions
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/tavomoya/mangagram/actions/i18n"
+	"github.com/tavomoya/mangagram/actions/rss"
+	"github.com/tavomoya/mangagram/models"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// ExportVersion is the version of the format of
+// the files written by ExportSubscriptions.
+const ExportVersion = 1
+
+// MaxImportSize is the size, in bytes, of the largest
+// file with subscriptions that can be imported.
+const MaxImportSize = 1 << 20
+
+// maxImportEntries is the most subscriptions
+// a single file can import.
+const maxImportEntries = 500
+
+// ErrInvalidExport is returned when a file with
+// subscriptions can't be read or has invalid ones.
+var ErrInvalidExport = errors.New("the file is not a MangaGram export")
+
+// SubscriptionExport is the file with a chat's subscriptions
+// written by /export, which /import reads back.
+type SubscriptionExport struct {
+	Version       int                    `json:"version"`
+	ExportedAt    time.Time              `json:"exported_at"`
+	Subscriptions []ExportedSubscription `json:"subscriptions"`
+}
+
+// ExportedSubscription is a subscription in an export.
+// FeedName is only there for people reading the file.
+type ExportedSubscription struct {
+	MangaName   string `json:"manga_name"`
+	Feed        int    `json:"feed"`
+	FeedName    string `json:"feed_name,omitempty"`
+	URL         string `json:"url"`
+	LastChapter string `json:"last_chapter,omitempty"`
+}
+
+// ImportResult is what happened to the
+// subscriptions of an imported file.
+type ImportResult struct {
+	// Subscriptions created
+	Imported int
+
+	// Subscriptions the chat already had
+	Duplicates int
+
+	// Names of the titles that couldn't be subscribed
+	Failed []string
+
+	// Titles of a library found in a feed and subscribed
+	// to, with the feed's name, see ImportLibrary
+	Matched []string
+
+	// Titles of a library that weren't found in any feed
+	Unmatched []string
+}
+
+// ExportSubscriptions method returns the subscriptions of a Chat as
+// an indented SubscriptionExport JSON document. It might return an
+// error if the chat's subscriptions can't be queried.
+func ExportSubscriptions(db *models.DatabaseConfig, chatID int64) ([]byte, error) {
+
+	subs, err := GetChatSubscriptions(db, chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	export := SubscriptionExport{
+		Version:       ExportVersion,
+		ExportedAt:    time.Now().UTC(),
+		Subscriptions: make([]ExportedSubscription, 0, len(subs)),
+	}
+
+	for _, s := range subs {
+		export.Subscriptions = append(export.Subscriptions, ExportedSubscription{
+			MangaName:   s.MangaName,
+			Feed:        s.MangaFeed,
+			FeedName:    FeedName(s.MangaFeed),
+			URL:         s.MangaURL,
+			LastChapter: s.LastChapterURL,
+		})
+	}
+
+	return json.MarshalIndent(export, "", "  ")
+}
+
+// ParseSubscriptionExport function reads the subscriptions of a file
+// written by ExportSubscriptions. It returns an error, wrapping
+// ErrInvalidExport, if the file can't be read or any subscription has
+// no name, an invalid URL, a feed that's not available or a URL that's
+// not a page of its feed.
+func ParseSubscriptionExport(data []byte) ([]ExportedSubscription, error) {
+
+	export := SubscriptionExport{}
+	err := json.Unmarshal(data, &export)
+	if err != nil {
+		return nil, ErrInvalidExport
+	}
+
+	if export.Version != ExportVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidExport, export.Version)
+	}
+
+	if len(export.Subscriptions) == 0 {
+		return nil, fmt.Errorf("%w: there are no subscriptions", ErrInvalidExport)
+	}
+
+	if len(export.Subscriptions) > maxImportEntries {
+		return nil, fmt.Errorf("%w: there are more than %d subscriptions", ErrInvalidExport, maxImportEntries)
+	}
+
+	for i, s := range export.Subscriptions {
+		u, err := url.Parse(s.URL)
+		if strings.TrimSpace(s.MangaName) == "" || err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("%w: subscription %d has no name or an invalid URL", ErrInvalidExport, i+1)
+		}
+
+		feed := NewMangaInterface(s.Feed, nil)
+		if feed == nil {
+			return nil, fmt.Errorf("%w: the feed %d of %s is not available", ErrInvalidExport, s.Feed, s.MangaName)
+		}
+
+		if !feedPage(feed, u) {
+			return nil, fmt.Errorf("%w: the URL of %s is not a page of the feed %d", ErrInvalidExport, s.MangaName, s.Feed)
+		}
+	}
+
+	return export.Subscriptions, nil
+}
+
+// ImportSubscriptions method subscribes a Chat to the subscriptions
+// of an export, on behalf of a user. The ones the chat already has,
+// to the same title or manga series, and the ones repeated in the
+// export are skipped. It might return an error if the chat's
+// subscriptions can't be queried.
+func ImportSubscriptions(db *models.DatabaseConfig, chatID int64, user *tb.User, subs []ExportedSubscription) (*ImportResult, error) {
+
+	settings := GetChatSettings(db, chatID)
+
+	existing, err := GetChatSubscriptions(db, chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	for _, s := range existing {
+		seen[s.MangaURL] = true
+	}
+
+	result := &ImportResult{}
+	for _, s := range subs {
+		if seen[s.URL] {
+			result.Duplicates++
+			continue
+		}
+		seen[s.URL] = true
+
+		feed := NewMangaInterface(s.Feed, db)
+		if feed == nil {
+			result.Failed = append(result.Failed, s.MangaName)
+			continue
+		}
+
+		SetFeedLanguages(feed, settings.Languages)
+
+		sub := &models.Subscription{
+			UserID:         user.ID,
+			UserName:       user.FirstName,
+			ChatID:         chatID,
+			MangaName:      s.MangaName,
+			MangaURL:       s.URL,
+			MangaFeed:      s.Feed,
+			LastChapterURL: s.LastChapter,
+		}
+
+		_, err := SubscribeManga(db, feed, sub)
+		if err == ErrAlreadySubscribed {
+			result.Duplicates++
+			continue
+		}
+		if err != nil {
+			log.Println("There was an error importing the subscription: ", err)
+			result.Failed = append(result.Failed, s.MangaName)
+			continue
+		}
+
+		result.Imported++
+	}
+
+	return result, nil
+}
+
+// ImportMessage function returns the message that tells a chat, in
+// a locale, what happened to the subscriptions it imported: the titles
+// of a library that were found and the ones that weren't, and the ones
+// that couldn't be subscribed. It's truncated to fit in a Telegram message.
+func ImportMessage(locale string, result *ImportResult) string {
+	lines := []string{i18n.T(locale, "import.done", result.Imported, result.Duplicates)}
+	length := utf8.RuneCountInString(lines[0])
+
+	sections := []struct {
+		title string
+		names []string
+	}{
+		{"import.matched", result.Matched},
+		{"import.unmatched", result.Unmatched},
+		{"import.failed", result.Failed},
+	}
+
+	for _, section := range sections {
+		if len(section.names) == 0 {
+			continue
+		}
+
+		title := i18n.T(locale, section.title)
+		lines = append(lines, "", title)
+		length += utf8.RuneCountInString(title) + 2
+
+		for _, name := range section.names {
+			line := "• " + html.EscapeString(name)
+
+			length += utf8.RuneCountInString(line) + 1
+			if length > maxMessageLength-2 {
+				return strings.Join(append(lines, "…"), "\n")
+			}
+
+			lines = append(lines, line)
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// feedPage reports whether a URL is a page of a feed: it's on the host
+// of the feed's ViewManga URL and under its path. RSS feeds can be on
+// any host, their client only connects to public addresses.
+func feedPage(feed MangaFeedInterface, u *url.URL) bool {
+	base, err := url.Parse(fmt.Sprintf(feed.ViewManga(), ""))
+	if err != nil {
+		return false
+	}
+
+	if _, isRSS := feed.(*rss.RSSFeed); isRSS {
+		return true
+	}
+
+	return base.Host != "" && u.Scheme == base.Scheme && strings.EqualFold(u.Host, base.Host) && strings.HasPrefix(u.Path, base.Path)
+}
@@ -0,0 +1,153 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/tavomoya/mangagram/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+func TestExportSubscriptions(t *testing.T) {
+	opts := &mtest.Options{}
+	opts.ClientType(mtest.Mock)
+	opts.CollectionName("subscription")
+	opts.DatabaseName("mangagram")
+	opts.ShareClient(true)
+
+	mt := mtest.New(t, opts)
+	defer mt.Close()
+
+	is := is.New(t)
+	config := &models.DatabaseConfig{
+		Ctx:         context.Background(),
+		MongoClient: mt.Client.Database("mangagram"),
+	}
+
+	mt.Run("Nil Database", func(t *mtest.T) {
+		data, err := ExportSubscriptions(nil, 1)
+		is.True(data == nil)
+		is.True(err != nil)
+	})
+
+	mt.Run("Success", func(t *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "subscription.subscription", mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: primitive.NewObjectID()},
+			{Key: "manganame", Value: "One Piece"},
+			{Key: "mangaurl", Value: "https://manganelo.com/manga/one_piece"},
+			{Key: "lastchapterurl", Value: "https://manganelo.com/chapter/one_piece/chapter_1000"},
+			{Key: "mangafeed", Value: 2},
+		}))
+
+		data, err := ExportSubscriptions(config, 1)
+		is.NoErr(err)
+
+		export := SubscriptionExport{}
+		is.NoErr(json.Unmarshal(data, &export))
+		is.Equal(export.Version, ExportVersion)
+		is.Equal(export.Subscriptions, []ExportedSubscription{{
+			MangaName:   "One Piece",
+			Feed:        2,
+			FeedName:    "Manganelo",
+			URL:         "https://manganelo.com/manga/one_piece",
+			LastChapter: "https://manganelo.com/chapter/one_piece/chapter_1000",
+		}})
+
+		// Exports can be imported back
+		subs, err := ParseSubscriptionExport(data)
+		is.NoErr(err)
+		is.Equal(subs, export.Subscriptions)
+	})
+}
+
+func TestParseSubscriptionExport(t *testing.T) {
+	is := is.New(t)
+
+	invalid := map[string]string{
+		"Not JSON":       `One Piece`,
+		"Other version":  `{"version": 2, "subscriptions": [{"manga_name": "One Piece", "feed": 2, "url": "https://manganelo.com/manga/one_piece"}]}`,
+		"Empty":          `{"version": 1, "subscriptions": []}`,
+		"No name":        `{"version": 1, "subscriptions": [{"feed": 2, "url": "https://manganelo.com/manga/one_piece"}]}`,
+		"Invalid URL":    `{"version": 1, "subscriptions": [{"manga_name": "One Piece", "feed": 2, "url": "javascript:alert(1)"}]}`,
+		"Unknown feed":   `{"version": 1, "subscriptions": [{"manga_name": "One Piece", "feed": 99, "url": "https://manganelo.com/manga/one_piece"}]}`,
+		"Missing fields": `{"subscriptions": [{}]}`,
+		"Other host":     `{"version": 1, "subscriptions": [{"manga_name": "One Piece", "feed": 2, "url": "http://169.254.169.254/manga/one_piece"}]}`,
+		"Similar host":   `{"version": 1, "subscriptions": [{"manga_name": "One Piece", "feed": 3, "url": "https://mangaeden.com.example.com/en/one-piece"}]}`,
+		"Other path":     `{"version": 1, "subscriptions": [{"manga_name": "One Piece", "feed": 2, "url": "https://manganelo.com/admin"}]}`,
+	}
+
+	for name, data := range invalid {
+		t.Run(name, func(t *testing.T) {
+			is := is.New(t)
+
+			subs, err := ParseSubscriptionExport([]byte(data))
+			is.True(subs == nil)
+			is.True(errors.Is(err, ErrInvalidExport))
+		})
+	}
+
+	subs, err := ParseSubscriptionExport([]byte(`{"version": 1, "subscriptions": [{"manga_name": "Blog", "feed": 6, "url": "https://example.com/feed.xml"}]}`))
+	is.NoErr(err)
+	is.Equal(len(subs), 1)
+}
+
+func TestImportSubscriptions(t *testing.T) {
+	opts := &mtest.Options{}
+	opts.ClientType(mtest.Mock)
+	opts.CollectionName("subscription")
+	opts.DatabaseName("mangagram")
+	opts.ShareClient(true)
+
+	mt := mtest.New(t, opts)
+	defer mt.Close()
+
+	is := is.New(t)
+	config := &models.DatabaseConfig{
+		Ctx:         context.Background(),
+		MongoClient: mt.Client.Database("mangagram"),
+	}
+
+	mt.Run("Duplicates", func(t *mtest.T) {
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "chat_settings.chat_settings", mtest.FirstBatch),
+			mtest.CreateCursorResponse(0, "subscription.subscription", mtest.FirstBatch, bson.D{
+				{Key: "_id", Value: primitive.NewObjectID()},
+				{Key: "manganame", Value: "One Piece"},
+				{Key: "mangaurl", Value: "https://manganelo.com/manga/one_piece"},
+				{Key: "mangafeed", Value: 2},
+			}),
+		)
+
+		subs := []ExportedSubscription{
+			{MangaName: "One Piece", Feed: 2, URL: "https://manganelo.com/manga/one_piece"},
+			{MangaName: "Blog", Feed: 99, URL: "https://example.com/feed.xml"},
+			{MangaName: "Blog", Feed: 99, URL: "https://example.com/feed.xml"},
+		}
+
+		result, err := ImportSubscriptions(config, 1, &tb.User{ID: 1, FirstName: "Luffy"}, subs)
+		is.NoErr(err)
+		is.Equal(result, &ImportResult{Duplicates: 2, Failed: []string{"Blog"}})
+	})
+}
+
+func TestImportMessage(t *testing.T) {
+	is := is.New(t)
+
+	msg := ImportMessage("en", &ImportResult{Imported: 2, Duplicates: 1, Failed: []string{"Naruto & Boruto"}})
+	is.True(strings.HasPrefix(msg, "Imported 2 subscriptions, 1 were already in this chat"))
+	is.True(strings.Contains(msg, "• Naruto &amp; Boruto"))
+
+	msg = ImportMessage("en", &ImportResult{Imported: 1, Matched: []string{"One Piece (Mangadex)"}, Unmatched: []string{"Berserk"}})
+	is.True(strings.Contains(msg, "Subscribed to:\n• One Piece (Mangadex)"))
+	is.True(strings.Contains(msg, "Not found in any feed:\n• Berserk"))
+
+	msg = ImportMessage("en", &ImportResult{Imported: 1})
+	is.Equal(msg, "Imported 1 subscriptions, 0 were already in this chat")
+}
@@ -0,0 +1,266 @@
+package actions
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/tavomoya/mangagram/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// ErrNotFollower is returned when a member of a group chat
+// removes a personal subscription they don't follow.
+var ErrNotFollower = errors.New("the user doesn't follow this manga")
+
+// NewFollower function returns the follower
+// of a subscription for a Telegram user.
+func NewFollower(user *tb.User) models.Follower {
+	return models.Follower{
+		UserID:    user.ID,
+		UserName:  user.Username,
+		FirstName: user.FirstName,
+	}
+}
+
+// IsFollower function reports whether a user
+// follows a subscription of a group chat.
+func IsFollower(sub *models.Subscription, userID int) bool {
+	for _, f := range sub.Followers {
+		if f.UserID == userID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FollowManga method makes a member of a group chat follow a feed's title.
+// If the group is already subscribed to the title's manga series the member
+// is added to its followers; otherwise a personal subscription, which only
+// belongs to its followers, is created (see the feed's Subscribe method).
+// It returns the subscription followed, or ErrAlreadySubscribed if the
+// member already follows it.
+func FollowManga(db *models.DatabaseConfig, feed MangaFeedInterface, sub *models.Subscription, follower models.Follower) (*models.Subscription, error) {
+
+	if LinkSubscription(db, sub) == nil {
+		existing, err := GetChatMangaSubscription(db, sub.ChatID, sub.MangaID)
+		if err != nil {
+			return nil, err
+		}
+
+		if existing != nil {
+			if IsFollower(existing, follower.UserID) {
+				return existing, ErrAlreadySubscribed
+			}
+
+			return existing, FollowSubscription(db, existing, follower)
+		}
+	}
+
+	err := ClearRemovedSubscription(db, sub.ChatID, sub.MangaURL)
+	if err != nil {
+		return nil, err
+	}
+
+	sub.Personal = true
+	sub.Followers = []models.Follower{follower}
+
+	err = feed.Subscribe(sub)
+	if err != nil {
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+// FollowSubscription method adds a member of a group
+// chat to the followers of one of its subscriptions.
+func FollowSubscription(db *models.DatabaseConfig, sub *models.Subscription, follower models.Follower) error {
+
+	if db == nil {
+		log.Println("The DB model is nil")
+		return errors.New("the DB model passed is nil, can't operate")
+	}
+
+	if IsFollower(sub, follower.UserID) {
+		return nil
+	}
+
+	_, err := db.MongoClient.Collection("subscription").UpdateOne(
+		db.Ctx,
+		bson.M{"_id": sub.ID},
+		bson.M{
+			"$push": bson.M{"followers": follower},
+			"$pull": bson.M{"unfollowed": bson.M{"userid": follower.UserID}},
+		},
+	)
+	if err != nil {
+		log.Println("There was an error following the subscription: ", err)
+		return err
+	}
+
+	sub.Followers = append(sub.Followers, follower)
+
+	return nil
+}
+
+// UnfollowManga method removes a member of a group chat from the
+// followers of a subscription, and keeps them in its unfollowed members
+// to follow it again with RestoreMemberSubscription. Personal
+// subscriptions are removed with their last follower. It returns ErrNotFollower if the member
+// doesn't follow the subscription.
+func UnfollowManga(db *models.DatabaseConfig, sub *models.Subscription, userID int) error {
+
+	if db == nil {
+		log.Println("The DB model is nil")
+		return errors.New("the DB model passed is nil, can't operate")
+	}
+
+	if !IsFollower(sub, userID) {
+		return ErrNotFollower
+	}
+
+	if sub.Personal && len(sub.Followers) == 1 {
+		return RemoveMangaSubscription(db, sub.ID.Hex())
+	}
+
+	unfollowed := models.Follower{UserID: userID, UnfollowedAt: time.Now()}
+	for _, f := range sub.Followers {
+		if f.UserID == userID {
+			unfollowed = f
+			unfollowed.UnfollowedAt = time.Now()
+		}
+	}
+
+	_, err := db.MongoClient.Collection("subscription").UpdateOne(
+		db.Ctx,
+		bson.M{"_id": sub.ID},
+		bson.M{
+			"$pull": bson.M{"followers": bson.M{"userid": userID}},
+			"$push": bson.M{"unfollowed": unfollowed},
+		},
+	)
+	if err != nil {
+		log.Println("There was an error unfollowing the subscription: ", err)
+		return err
+	}
+
+	followers := []models.Follower{}
+	for _, f := range sub.Followers {
+		if f.UserID != userID {
+			followers = append(followers, f)
+		}
+	}
+	sub.Followers = followers
+
+	return nil
+}
+
+// RemoveMemberSubscription method removes a subscription for the member
+// of a chat who asked for it. Personal subscriptions are only unfollowed,
+// see UnfollowManga; shared ones are removed for the whole chat.
+func RemoveMemberSubscription(db *models.DatabaseConfig, subscriptionID string, userID int) error {
+
+	sub, err := GetSubscription(db, subscriptionID)
+	if err != nil {
+		return err
+	}
+
+	if sub.Personal {
+		return UnfollowManga(db, sub, userID)
+	}
+
+	return RemoveMangaSubscription(db, subscriptionID)
+}
+
+// RestoreMemberSubscription method undoes RemoveMemberSubscription for
+// the member of a chat who asked for it: removed subscriptions are
+// restored, see RestoreSubscription, and personal ones the member
+// stopped following are followed again. Personal subscriptions are only
+// restored for the member who removed them, it returns ErrNotFollower
+// for anyone else, and ErrUndoExpired if the member stopped following
+// it longer than UndoWindow ago.
+func RestoreMemberSubscription(db *models.DatabaseConfig, subscriptionID string, follower models.Follower) error {
+
+	sub, err := GetSubscription(db, subscriptionID)
+	if err != nil {
+		return err
+	}
+
+	// Personal subscriptions keep the last follower when removed
+	if !sub.DeletedAt.IsZero() {
+		if sub.Personal && !IsFollower(sub, follower.UserID) {
+			return ErrNotFollower
+		}
+
+		return RestoreSubscription(db, subscriptionID)
+	}
+
+	if !sub.Personal || IsFollower(sub, follower.UserID) {
+		return nil
+	}
+
+	unfollowed := false
+	for _, f := range sub.Unfollowed {
+		unfollowed = unfollowed || f.UserID == follower.UserID
+	}
+
+	if !unfollowed {
+		return ErrNotFollower
+	}
+
+	res, err := db.MongoClient.Collection("subscription").UpdateOne(
+		db.Ctx,
+		bson.M{
+			"_id": sub.ID,
+			"unfollowed": bson.M{"$elemMatch": bson.M{
+				"userid":       follower.UserID,
+				"unfollowedat": bson.M{"$gte": time.Now().Add(-UndoWindow)},
+			}},
+		},
+		bson.M{
+			"$pull": bson.M{"unfollowed": bson.M{"userid": follower.UserID}},
+			"$push": bson.M{"followers": follower},
+		},
+	)
+	if err != nil {
+		log.Println("There was an error following the subscription again: ", err)
+		return err
+	}
+
+	if res.MatchedCount != 1 {
+		return ErrUndoExpired
+	}
+
+	return nil
+}
+
+// Mentions function returns the mentions of the followers of a
+// subscription for a message built with a template mode. Users
+// without a username can only be mentioned in HTML and MarkdownV2
+// messages, text ones just name them.
+func Mentions(mode string, followers []models.Follower) string {
+	mentions := make([]string, 0, len(followers))
+	for _, f := range followers {
+		switch {
+		case f.UserName != "" && mode == TemplateMarkdown:
+			mentions = append(mentions, markdownEscaper.Replace("@"+f.UserName))
+		case f.UserName != "":
+			mentions = append(mentions, "@"+f.UserName)
+		case mode == TemplateHTML:
+			mentions = append(mentions, fmt.Sprintf(`<a href="tg://user?id=%d">%s</a>`, f.UserID, html.EscapeString(f.FirstName)))
+		case mode == TemplateMarkdown:
+			mentions = append(mentions, fmt.Sprintf("[%s](tg://user?id=%d)", markdownEscaper.Replace(f.FirstName), f.UserID))
+		default:
+			mentions = append(mentions, f.FirstName)
+		}
+	}
+
+	return strings.Join(mentions, " ")
+}
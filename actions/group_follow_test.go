@@ -0,0 +1,205 @@
+package actions
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/tavomoya/mangagram/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestUnfollowManga(t *testing.T) {
+	opts := &mtest.Options{}
+	opts.ClientType(mtest.Mock)
+	opts.CollectionName("subscription")
+	opts.DatabaseName("mangagram")
+	opts.ShareClient(true)
+
+	mt := mtest.New(t, opts)
+	defer mt.Close()
+
+	is := is.New(t)
+	config := &models.DatabaseConfig{
+		Ctx:         context.Background(),
+		MongoClient: mt.Client.Database("mangagram"),
+	}
+
+	followers := []models.Follower{{UserID: 1, UserName: "luffy"}, {UserID: 2, FirstName: "Zoro"}}
+
+	mt.Run("Nil Database", func(t *mtest.T) {
+		err := UnfollowManga(nil, &models.Subscription{Followers: followers}, 1)
+		is.True(err != nil)
+	})
+
+	mt.Run("Not a follower", func(t *mtest.T) {
+		err := UnfollowManga(config, &models.Subscription{Followers: followers}, 3)
+		is.Equal(err, ErrNotFollower)
+	})
+
+	mt.Run("Other followers left", func(t *mtest.T) {
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}})
+
+		sub := &models.Subscription{ID: primitive.NewObjectID(), Personal: true, Followers: followers}
+		err := UnfollowManga(config, sub, 1)
+		is.NoErr(err)
+		is.Equal(sub.Followers, []models.Follower{{UserID: 2, FirstName: "Zoro"}})
+	})
+
+	mt.Run("Last follower of a personal subscription", func(t *mtest.T) {
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}})
+
+		sub := &models.Subscription{ID: primitive.NewObjectID(), Personal: true, Followers: followers[:1]}
+		err := UnfollowManga(config, sub, 1)
+		is.NoErr(err)
+	})
+}
+
+func TestRemoveMemberSubscription(t *testing.T) {
+	opts := &mtest.Options{}
+	opts.ClientType(mtest.Mock)
+	opts.CollectionName("subscription")
+	opts.DatabaseName("mangagram")
+	opts.ShareClient(true)
+
+	mt := mtest.New(t, opts)
+	defer mt.Close()
+
+	is := is.New(t)
+	config := &models.DatabaseConfig{
+		Ctx:         context.Background(),
+		MongoClient: mt.Client.Database("mangagram"),
+	}
+
+	mt.Run("Personal subscription of another member", func(t *mtest.T) {
+		id := primitive.NewObjectID()
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "subscription.subscription", mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: id},
+			{Key: "personal", Value: true},
+			{Key: "followers", Value: bson.A{bson.D{{Key: "userid", Value: 1}}}},
+		}))
+
+		err := RemoveMemberSubscription(config, id.Hex(), 2)
+		is.Equal(err, ErrNotFollower)
+	})
+
+	mt.Run("Shared subscription", func(t *mtest.T) {
+		id := primitive.NewObjectID()
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "subscription.subscription", mtest.FirstBatch, bson.D{
+				{Key: "_id", Value: id},
+				{Key: "followers", Value: bson.A{bson.D{{Key: "userid", Value: 1}}}},
+			}),
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}},
+		)
+
+		err := RemoveMemberSubscription(config, id.Hex(), 2)
+		is.NoErr(err)
+	})
+}
+
+func TestRestoreMemberSubscription(t *testing.T) {
+	opts := &mtest.Options{}
+	opts.ClientType(mtest.Mock)
+	opts.CollectionName("subscription")
+	opts.DatabaseName("mangagram")
+	opts.ShareClient(true)
+
+	mt := mtest.New(t, opts)
+	defer mt.Close()
+
+	is := is.New(t)
+	config := &models.DatabaseConfig{
+		Ctx:         context.Background(),
+		MongoClient: mt.Client.Database("mangagram"),
+	}
+
+	mt.Run("Removed subscription", func(t *mtest.T) {
+		id := primitive.NewObjectID()
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "subscription.subscription", mtest.FirstBatch, bson.D{
+				{Key: "_id", Value: id},
+				{Key: "deleted_at", Value: time.Now()},
+			}),
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}},
+		)
+
+		err := RestoreMemberSubscription(config, id.Hex(), models.Follower{UserID: 2})
+		is.NoErr(err)
+	})
+
+	mt.Run("Personal subscription unfollowed", func(t *mtest.T) {
+		id := primitive.NewObjectID()
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "subscription.subscription", mtest.FirstBatch, bson.D{
+				{Key: "_id", Value: id},
+				{Key: "personal", Value: true},
+				{Key: "followers", Value: bson.A{bson.D{{Key: "userid", Value: 1}}}},
+				{Key: "unfollowed", Value: bson.A{bson.D{{Key: "userid", Value: 2}, {Key: "unfollowedat", Value: time.Now()}}}},
+			}),
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}},
+		)
+
+		err := RestoreMemberSubscription(config, id.Hex(), models.Follower{UserID: 2})
+		is.NoErr(err)
+	})
+
+	mt.Run("Personal subscription unfollowed long ago", func(t *mtest.T) {
+		id := primitive.NewObjectID()
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "subscription.subscription", mtest.FirstBatch, bson.D{
+				{Key: "_id", Value: id},
+				{Key: "personal", Value: true},
+				{Key: "followers", Value: bson.A{bson.D{{Key: "userid", Value: 1}}}},
+				{Key: "unfollowed", Value: bson.A{bson.D{{Key: "userid", Value: 2}, {Key: "unfollowedat", Value: time.Now().Add(-time.Hour)}}}},
+			}),
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 0}, {Key: "nModified", Value: 0}},
+		)
+
+		err := RestoreMemberSubscription(config, id.Hex(), models.Follower{UserID: 2})
+		is.Equal(err, ErrUndoExpired)
+	})
+
+	mt.Run("Personal subscription of another member", func(t *mtest.T) {
+		id := primitive.NewObjectID()
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "subscription.subscription", mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: id},
+			{Key: "personal", Value: true},
+			{Key: "followers", Value: bson.A{bson.D{{Key: "userid", Value: 1}}}},
+			{Key: "unfollowed", Value: bson.A{bson.D{{Key: "userid", Value: 2}, {Key: "unfollowedat", Value: time.Now()}}}},
+		}))
+
+		err := RestoreMemberSubscription(config, id.Hex(), models.Follower{UserID: 3})
+		is.Equal(err, ErrNotFollower)
+	})
+
+	mt.Run("Removed personal subscription of another member", func(t *mtest.T) {
+		id := primitive.NewObjectID()
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "subscription.subscription", mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: id},
+			{Key: "personal", Value: true},
+			{Key: "followers", Value: bson.A{bson.D{{Key: "userid", Value: 1}}}},
+			{Key: "deleted_at", Value: time.Now()},
+		}))
+
+		err := RestoreMemberSubscription(config, id.Hex(), models.Follower{UserID: 2})
+		is.Equal(err, ErrNotFollower)
+	})
+}
+
+func TestMentions(t *testing.T) {
+	is := is.New(t)
+
+	followers := []models.Follower{
+		{UserID: 1, UserName: "monkey_d"},
+		{UserID: 2, FirstName: "Zoro <3"},
+	}
+
+	is.Equal(Mentions(TemplateText, followers), "@monkey_d Zoro <3")
+	is.Equal(Mentions(TemplateHTML, followers), `@monkey_d <a href="tg://user?id=2">Zoro &lt;3</a>`)
+	is.Equal(Mentions(TemplateMarkdown, followers), `@monkey\_d [Zoro <3](tg://user?id=2)`)
+	is.Equal(Mentions(TemplateHTML, nil), "")
+}
@@ -0,0 +1,432 @@
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultLocale is the locale used for chats that haven't
+// chosen one and whose users' language is not supported.
+const DefaultLocale = "en"
+
+// messages holds every user-facing message, by locale
+// and key. Messages are fmt format strings.
+var messages = map[string]map[string]string{
+	"en": {
+		"language.name": "English",
+
+		"start": "Hi! This is MangaGram, a Telegram bot for alerts on your favorite manga titles.\n\n" +
+			"%s\n\n" +
+			"If you need help use the /help command.\n\n" +
+			"MangaGram v1.1.3 Made with ❤️ by @tavomoya.",
+		"help": "<b>Available Commmands:</b>\n" +
+			"/manga {title} - Get a list of mangas that match the title\n" +
+			"/info {title} - Get the cover, author, status and synopsis of a manga\n" +
+			"/subscriptions - Get a list of the chat's current manga subscriptions\n" +
+			"/export - Get a file with the chat's subscriptions\n" +
+			"/import - Subscribe to the titles of a file made with /export or a Tachiyomi backup\n" +
+			"/unread - Get the titles you have unread chapters of\n" +
+			"/setfeed - Change manga feed used for manga searches (defaults to Manga Reader)\n" +
+			"/follow_rss {url} - Get alerts for every new item in a RSS or Atom feed\n" +
+			"/chapterlang {codes} - Choose the languages of the chapters you get (e.g. en es)\n" +
+			"/language - Change the language the bot talks to you in\n" +
+			"/settings - Change this chat's settings\n" +
+			"/timezone {name} - Set this chat's timezone (e.g. America/Santo_Domingo)\n" +
+			"/template - Customize the alerts you get\n" +
+			"/help - Info about available commands and mangafeeds\n\n" +
+			"<b>Manga Feeds</b>\n" +
+			"%s\n\n" +
+			"You can set your favorite one using the /setfeed command.",
+
+		"manga.no_name":    "<b>No manga name supplied</b>",
+		"manga.not_found":  "No Manga found with your criteria",
+		"manga.found":      "These are the manga I found:\n",
+		"manga.subscribe":  "Subscribe 🔔",
+		"manga.subscribed": "Succesfully subscribed",
+		"manga.details":    "Details ℹ️",
+		"manga.back":       "« Back",
+		"manga.expired":    "This search is too old, use /manga again",
+		"manga.duplicate":  "This chat is already subscribed to %s on %s",
+		"manga.follow":     "Follow 👤",
+
+		"info.no_name":  "<b>No manga name supplied</b>",
+		"info.error":    "There was an error getting this manga's info",
+		"info.author":   "Author: %s",
+		"info.status":   "Status: %s",
+		"info.genres":   "Genres: %s",
+		"info.chapters": "Latest chapters:",
+		"info.read":     "Read 📖",
+
+		"inline.help":      "Type a manga title to share it",
+		"inline.not_found": "No manga found, search it in the bot",
+		"inline.subscribe": "Subscribe in bot 🔔",
+
+		"rss.no_url":     "<b>No feed URL supplied</b>",
+		"rss.not_found":  "Couldn't find a RSS or Atom feed in that URL",
+		"rss.error":      "There was an error following this feed",
+		"rss.subscribed": "Succesfully subscribed to <b>%s</b>",
+
+		"chapterlang.feed_error":  "There was an error getting this chat's feed",
+		"chapterlang.current":     "Chapter languages: <b>%s</b>\nAvailable in this feed: %s\n\nUse /chapterlang {codes} to change them, e.g. /chapterlang en es",
+		"chapterlang.unsupported": "None of those languages are available in this feed: %s",
+		"chapterlang.error":       "There was an error saving your languages",
+		"chapterlang.saved":       "Chapter languages set to: %s",
+
+		"subscriptions.empty":   "<b>You're not subscribed to any mangas yet.</b>",
+		"subscriptions.list":    "Current Subscriptions:\n",
+		"subscriptions.remove":  "Remove ❌",
+		"subscriptions.removed": "Subscription removed",
+		"subscriptions.share":   "Share 🔗",
+		"subscriptions.follow":  "Follow 👤",
+
+		"subscriptions.not_follower": "This title is followed by other members, only they can remove it",
+
+		"remove.confirm":   "Remove <b>%s</b> from this chat's subscriptions?",
+		"remove.done":      "<b>%s</b> was removed. You can undo it for %d minutes.",
+		"remove.undo":      "Undo ↩️",
+		"remove.restored":  "<b>%s</b> was restored",
+		"remove.expired":   "It's too late to undo this",
+		"remove.not_yours": "Only the member who removed this title can undo it",
+		"confirm.cancel":   "Cancel",
+
+		"group.followed":          "You follow %s, you'll be mentioned in its alerts",
+		"group.unfollowed":        "You don't follow %s anymore",
+		"group.already_following": "You already follow %s",
+
+		"deeplink.invalid":    "This link is not valid, search the manga with /manga",
+		"deeplink.error":      "There was an error subscribing to this manga",
+		"deeplink.subscribed": "Succesfully subscribed to <b>%s</b> on %s",
+
+		"setfeed.select":   "Select feed:\n\n<b>Keep in mind that selecting a different feed than the one you have will move your manga subscriptions to it, the ones that can't be found there are kept in their feed</b>",
+		"setfeed.changed":  "Feed changed",
+		"setfeed.migrated": "%d of %d subscriptions were moved to %s",
+		"setfeed.confirm":  "Change the feed to <b>%s</b>?\n\nYour manga subscriptions will be moved to it, the ones that can't be found there are kept in their feed.",
+
+		"setfeed.confirm_yes": "Change feed ✅",
+
+		"language.select":  "Choose the language MangaGram talks to you in:",
+		"language.changed": "Language changed to English",
+		"language.error":   "There was an error changing the language",
+
+		"settings.title":          "<b>Chat settings</b>\nTap a setting to change it:",
+		"settings.feed":           "Feed: %s",
+		"settings.language":       "Language: %s",
+		"settings.format":         "Alerts: %s",
+		"settings.format_default": "Default",
+		"settings.format_compact": "Compact",
+		"settings.format_rich":    "Rich",
+		"settings.format_custom":  "Custom",
+		"settings.quiet":          "Quiet hours: %s",
+		"settings.timezone":       "Timezone: %s",
+		"settings.preview":        "Link previews: %s",
+		"settings.on":             "on",
+		"settings.off":            "off",
+		"settings.error":          "There was an error changing the settings",
+
+		"settings.managed_by":          "Managed by: %s",
+		"settings.managed_by_admins":   "Admins",
+		"settings.managed_by_everyone": "Everyone",
+
+		"permissions.denied": "Only this group's admins can do this",
+
+		"timezone.current": "This chat's timezone is <b>%s</b>\n\nUse /timezone {name} to change it, e.g. /timezone America/Santo_Domingo",
+		"timezone.invalid": "%s is not a valid timezone, use a name like America/Santo_Domingo",
+		"timezone.saved":   "Timezone set to <b>%s</b>",
+
+		"settings.delivery":         "Delivery: %s",
+		"settings.delivery_instant": "Instant",
+		"settings.delivery_daily":   "Daily digest",
+		"settings.delivery_weekly":  "Weekly digest",
+		"settings.digest_hour":      "Digest at: %02d:00",
+		"settings.digest_day":       "Digest day: %s",
+
+		"weekday.0": "Sunday",
+		"weekday.1": "Monday",
+		"weekday.2": "Tuesday",
+		"weekday.3": "Wednesday",
+		"weekday.4": "Thursday",
+		"weekday.5": "Friday",
+		"weekday.6": "Saturday",
+
+		"digest.title": "<b>New chapters</b>",
+		"digest.item":  "• %s: %s",
+
+		"template.help": "Alerts are built with Go templates (text/template). Use:\n" +
+			"/template {mode} {template} - Use your own template, mode is text, html or markdownv2\n" +
+			"/template reset - Go back to the default alerts\n\n" +
+			"Values: {{.MangaName}} {{.MangaURL}} {{.Chapter}} {{.ChapterTitle}} {{.ChapterURL}} {{.FeedName}}\n\n" +
+			"Example: /template html <b>{{.MangaName}}</b> #{{.Chapter}} <a href=\"{{.ChapterURL}}\">Read</a>",
+		"template.current":      "Your template (%s):\n\n%s",
+		"template.invalid_mode": "%s is not a template mode, use text, html or markdownv2",
+		"template.invalid":      "That template doesn't work: %s",
+		"template.saved":        "Template saved, this is how your alerts will look ☝️",
+		"template.reset":        "Alerts are back to the default format",
+
+		"alert.read":        "Read 📖",
+		"alert.mark_read":   "Mark read ✅",
+		"alert.unsubscribe": "Unsubscribe 🔕",
+		"alert.marked_read": "Marked as read",
+		"alert.error":       "There was an error, please try again",
+
+		"unread.title": "<b>Chapters you haven't read:</b>",
+		"unread.count": "%s: %s unread",
+		"unread.empty": "You're all caught up! Use Mark read ✅ in the alerts to keep track of what you've read",
+		"unread.error": "There was an error getting the chapters you haven't read",
+
+		"export.caption": "This chat's subscriptions, send this file with /import to subscribe to them again",
+		"export.error":   "There was an error exporting your subscriptions",
+
+		"import.help":    "Send a file made with /export, or a Tachiyomi or Mihon backup, with /import as its caption, or reply to it with /import",
+		"import.too_big": "This file is too big to be imported",
+		"import.error":   "There was an error importing your subscriptions",
+		"import.invalid": "This file can't be imported: %s",
+		"import.started": "Importing %d subscriptions, this might take a while…",
+		"import.done":    "Imported %d subscriptions, %d were already in this chat",
+		"import.failed":  "These couldn't be subscribed:",
+
+		"import.matched":        "Subscribed to:",
+		"import.unmatched":      "Not found in any feed:",
+		"import.backup_invalid": "This file is not a Tachiyomi or Mihon backup",
+		"import.backup_empty":   "There are no titles in this backup's library",
+		"import.backup_started": "Looking for the %d titles of your library in the feeds, this might take a while…",
+		"import.backup_limit":   "Only the first %d titles of your library are imported",
+		"import.wait":           "This chat is importing subscriptions or just did, try again in %d minutes",
+
+		"alert.template_default": "Here is a new chapter for {{.MangaName}}\n {{.ChapterURL}}",
+		"alert.template_compact": "{{.MangaName}}: {{.ChapterURL}}",
+		"alert.template_rich":    "📖 <b>{{.MangaName}}</b>{{if .Chapter}} · Chapter {{.Chapter}}{{end}}\n{{if .ChapterTitle}}{{.ChapterTitle}}\n{{end}}<a href=\"{{.ChapterURL}}\">Read on {{.FeedName}}</a>",
+	},
+	"es": {
+		"language.name": "Español",
+
+		"start": "¡Hola! Este es MangaGram, un bot de Telegram que te avisa de nuevos capítulos de tus mangas favoritos.\n\n" +
+			"%s\n\n" +
+			"Si necesitas ayuda usa el comando /help.\n\n" +
+			"MangaGram v1.1.3 Hecho con ❤️ por @tavomoya.",
+		"help": "<b>Comandos disponibles:</b>\n" +
+			"/manga {título} - Busca los mangas que coincidan con el título\n" +
+			"/info {título} - Muestra la portada, el autor, el estado y la sinopsis de un manga\n" +
+			"/subscriptions - Muestra las suscripciones de este chat\n" +
+			"/export - Obtén un archivo con las suscripciones del chat\n" +
+			"/import - Suscríbete a los títulos de un archivo hecho con /export o de una copia de Tachiyomi\n" +
+			"/unread - Muestra los mangas de los que tienes capítulos sin leer\n" +
+			"/setfeed - Cambia la fuente usada para buscar mangas (por defecto Manga Reader)\n" +
+			"/follow_rss {url} - Recibe avisos de cada nueva entrada de un feed RSS o Atom\n" +
+			"/chapterlang {códigos} - Elige los idiomas de los capítulos que recibes (ej. en es)\n" +
+			"/language - Cambia el idioma en el que te habla el bot\n" +
+			"/settings - Cambia la configuración de este chat\n" +
+			"/timezone {nombre} - Elige la zona horaria de este chat (ej. America/Santo_Domingo)\n" +
+			"/template - Personaliza los avisos que recibes\n" +
+			"/help - Información sobre los comandos y las fuentes disponibles\n\n" +
+			"<b>Fuentes de manga</b>\n" +
+			"%s\n\n" +
+			"Puedes elegir tu favorita con el comando /setfeed.",
+
+		"manga.no_name":    "<b>No indicaste el nombre del manga</b>",
+		"manga.not_found":  "No encontré mangas con ese criterio",
+		"manga.found":      "Estos son los mangas que encontré:\n",
+		"manga.subscribe":  "Suscribirse 🔔",
+		"manga.subscribed": "Suscripción creada",
+		"manga.details":    "Detalles ℹ️",
+		"manga.back":       "« Volver",
+		"manga.expired":    "Esta búsqueda es muy antigua, usa /manga de nuevo",
+		"manga.duplicate":  "Este chat ya está suscrito a %s en %s",
+		"manga.follow":     "Seguir 👤",
+
+		"info.no_name":  "<b>No indicaste el nombre del manga</b>",
+		"info.error":    "Hubo un error obteniendo la información de este manga",
+		"info.author":   "Autor: %s",
+		"info.status":   "Estado: %s",
+		"info.genres":   "Géneros: %s",
+		"info.chapters": "Últimos capítulos:",
+		"info.read":     "Leer 📖",
+
+		"inline.help":      "Escribe el título de un manga para compartirlo",
+		"inline.not_found": "No encontré ese manga, búscalo en el bot",
+		"inline.subscribe": "Suscribirse en el bot 🔔",
+
+		"rss.no_url":     "<b>No indicaste la URL del feed</b>",
+		"rss.not_found":  "No encontré un feed RSS o Atom en esa URL",
+		"rss.error":      "Hubo un error al seguir este feed",
+		"rss.subscribed": "Suscripción creada a <b>%s</b>",
+
+		"chapterlang.feed_error":  "Hubo un error al obtener la fuente de este chat",
+		"chapterlang.current":     "Idiomas de capítulos: <b>%s</b>\nDisponibles en esta fuente: %s\n\nUsa /chapterlang {códigos} para cambiarlos, ej. /chapterlang en es",
+		"chapterlang.unsupported": "Ninguno de esos idiomas está disponible en esta fuente: %s",
+		"chapterlang.error":       "Hubo un error al guardar tus idiomas",
+		"chapterlang.saved":       "Idiomas de capítulos: %s",
+
+		"subscriptions.empty":   "<b>Todavía no tienes suscripciones.</b>",
+		"subscriptions.list":    "Suscripciones actuales:\n",
+		"subscriptions.remove":  "Eliminar ❌",
+		"subscriptions.removed": "Suscripción eliminada",
+		"subscriptions.share":   "Compartir 🔗",
+		"subscriptions.follow":  "Seguir 👤",
+
+		"subscriptions.not_follower": "Otros miembros siguen este título, solo ellos pueden eliminarlo",
+
+		"remove.confirm":   "¿Eliminar <b>%s</b> de las suscripciones de este chat?",
+		"remove.done":      "<b>%s</b> se eliminó. Puedes deshacerlo durante %d minutos.",
+		"remove.undo":      "Deshacer ↩️",
+		"remove.restored":  "<b>%s</b> se restauró",
+		"remove.expired":   "Ya es tarde para deshacer esto",
+		"remove.not_yours": "Solo el miembro que eliminó este título puede deshacerlo",
+		"confirm.cancel":   "Cancelar",
+
+		"group.followed":          "Sigues %s, te mencionaré en sus alertas",
+		"group.unfollowed":        "Ya no sigues %s",
+		"group.already_following": "Ya sigues %s",
+
+		"deeplink.invalid":    "Este enlace no es válido, busca el manga con /manga",
+		"deeplink.error":      "Hubo un error suscribiéndote a este manga",
+		"deeplink.subscribed": "Suscripción creada a <b>%s</b> en %s",
+
+		"setfeed.select":   "Elige una fuente:\n\n<b>Ten en cuenta que elegir una fuente distinta a la actual moverá tus suscripciones a ella, las que no se encuentren allí se quedan en su fuente</b>",
+		"setfeed.changed":  "Fuente cambiada",
+		"setfeed.migrated": "%d de %d suscripciones se movieron a %s",
+		"setfeed.confirm":  "¿Cambiar la fuente a <b>%s</b>?\n\nTus suscripciones se moverán a ella, las que no se encuentren allí se quedan en su fuente.",
+
+		"setfeed.confirm_yes": "Cambiar fuente ✅",
+
+		"language.select":  "Elige el idioma en el que te habla MangaGram:",
+		"language.changed": "Idioma cambiado a español",
+		"language.error":   "Hubo un error al cambiar el idioma",
+
+		"settings.title":          "<b>Configuración del chat</b>\nToca una opción para cambiarla:",
+		"settings.feed":           "Fuente: %s",
+		"settings.language":       "Idioma: %s",
+		"settings.format":         "Avisos: %s",
+		"settings.format_default": "Normal",
+		"settings.format_compact": "Compacto",
+		"settings.format_rich":    "Detallado",
+		"settings.format_custom":  "Personalizado",
+		"settings.quiet":          "Horas de silencio: %s",
+		"settings.timezone":       "Zona horaria: %s",
+		"settings.preview":        "Vista previa de enlaces: %s",
+		"settings.on":             "sí",
+		"settings.off":            "no",
+		"settings.error":          "Hubo un error al cambiar la configuración",
+
+		"settings.managed_by":          "Administrado por: %s",
+		"settings.managed_by_admins":   "Administradores",
+		"settings.managed_by_everyone": "Todos",
+
+		"permissions.denied": "Solo los administradores de este grupo pueden hacer esto",
+
+		"timezone.current": "La zona horaria de este chat es <b>%s</b>\n\nUsa /timezone {nombre} para cambiarla, ej. /timezone America/Santo_Domingo",
+		"timezone.invalid": "%s no es una zona horaria válida, usa un nombre como America/Santo_Domingo",
+		"timezone.saved":   "Zona horaria cambiada a <b>%s</b>",
+
+		"settings.delivery":         "Envío: %s",
+		"settings.delivery_instant": "Al momento",
+		"settings.delivery_daily":   "Resumen diario",
+		"settings.delivery_weekly":  "Resumen semanal",
+		"settings.digest_hour":      "Resumen a las: %02d:00",
+		"settings.digest_day":       "Día del resumen: %s",
+
+		"weekday.0": "Domingo",
+		"weekday.1": "Lunes",
+		"weekday.2": "Martes",
+		"weekday.3": "Miércoles",
+		"weekday.4": "Jueves",
+		"weekday.5": "Viernes",
+		"weekday.6": "Sábado",
+
+		"digest.title": "<b>Nuevos capítulos</b>",
+		"digest.item":  "• %s: %s",
+
+		"template.help": "Los avisos se crean con plantillas de Go (text/template). Usa:\n" +
+			"/template {modo} {plantilla} - Usa tu propia plantilla, el modo es text, html o markdownv2\n" +
+			"/template reset - Vuelve a los avisos por defecto\n\n" +
+			"Valores: {{.MangaName}} {{.MangaURL}} {{.Chapter}} {{.ChapterTitle}} {{.ChapterURL}} {{.FeedName}}\n\n" +
+			"Ejemplo: /template html <b>{{.MangaName}}</b> #{{.Chapter}} <a href=\"{{.ChapterURL}}\">Leer</a>",
+		"template.current":      "Tu plantilla (%s):\n\n%s",
+		"template.invalid_mode": "%s no es un modo de plantilla, usa text, html o markdownv2",
+		"template.invalid":      "Esa plantilla no funciona: %s",
+		"template.saved":        "Plantilla guardada, así se verán tus avisos ☝️",
+		"template.reset":        "Los avisos vuelven al formato por defecto",
+
+		"alert.read":        "Leer 📖",
+		"alert.mark_read":   "Marcar leído ✅",
+		"alert.unsubscribe": "Desuscribirse 🔕",
+		"alert.marked_read": "Marcado como leído",
+		"alert.error":       "Hubo un error, inténtalo de nuevo",
+
+		"unread.title": "<b>Capítulos que no has leído:</b>",
+		"unread.count": "%s: %s sin leer",
+		"unread.empty": "¡Estás al día! Usa Marcar leído ✅ en las alertas para llevar la cuenta de lo que has leído",
+		"unread.error": "Hubo un error obteniendo los capítulos que no has leído",
+
+		"export.caption": "Las suscripciones de este chat, envía este archivo con /import para suscribirte a ellas de nuevo",
+		"export.error":   "Hubo un error exportando tus suscripciones",
+
+		"import.help":    "Envía un archivo hecho con /export, o una copia de seguridad de Tachiyomi o Mihon, con /import como descripción, o respóndelo con /import",
+		"import.too_big": "Este archivo es demasiado grande para importarlo",
+		"import.error":   "Hubo un error importando tus suscripciones",
+		"import.invalid": "Este archivo no se puede importar: %s",
+		"import.started": "Importando %d suscripciones, esto puede tardar un poco…",
+		"import.done":    "Se importaron %d suscripciones, %d ya estaban en este chat",
+		"import.failed":  "No se pudo suscribir a estas:",
+
+		"import.matched":        "Suscrito a:",
+		"import.unmatched":      "No se encontraron en ninguna fuente:",
+		"import.backup_invalid": "Este archivo no es una copia de seguridad de Tachiyomi o Mihon",
+		"import.backup_empty":   "No hay títulos en la biblioteca de esta copia de seguridad",
+		"import.backup_started": "Buscando los %d títulos de tu biblioteca en las fuentes, esto puede tardar un poco…",
+		"import.backup_limit":   "Solo se importan los primeros %d títulos de tu biblioteca",
+		"import.wait":           "Este chat está importando suscripciones o acaba de hacerlo, inténtalo de nuevo en %d minutos",
+
+		"alert.template_default": "Hay un nuevo capítulo de {{.MangaName}}\n {{.ChapterURL}}",
+		"alert.template_compact": "{{.MangaName}}: {{.ChapterURL}}",
+		"alert.template_rich":    "📖 <b>{{.MangaName}}</b>{{if .Chapter}} · Capítulo {{.Chapter}}{{end}}\n{{if .ChapterTitle}}{{.ChapterTitle}}\n{{end}}<a href=\"{{.ChapterURL}}\">Leer en {{.FeedName}}</a>",
+	},
+}
+
+// Locales function returns the codes of
+// every locale with a message catalog.
+func Locales() []string {
+	locales := make([]string, 0, len(messages))
+	for l := range messages {
+		locales = append(locales, l)
+	}
+	sort.Strings(locales)
+
+	return locales
+}
+
+// Locale function returns the supported locale that matches a
+// language code, like the ones Telegram reports for its users
+// (e.g. "es-419"). It returns DefaultLocale for unsupported codes.
+func Locale(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	if i := strings.IndexAny(code, "-_"); i >= 0 {
+		code = code[:i]
+	}
+
+	if _, ok := messages[code]; ok {
+		return code
+	}
+
+	return DefaultLocale
+}
+
+// T function returns the message with the given key in a locale,
+// formatted with args. Missing messages fall back to the default
+// locale, and to the key itself if the message doesn't exist.
+func T(locale, key string, args ...interface{}) string {
+	msg, ok := messages[Locale(locale)][key]
+	if !ok {
+		msg, ok = messages[DefaultLocale][key]
+	}
+
+	if !ok {
+		return key
+	}
+
+	if len(args) == 0 {
+		return msg
+	}
+
+	return fmt.Sprintf(msg, args...)
+}
@@ -0,0 +1,54 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestCatalogs(t *testing.T) {
+	is := is.New(t)
+
+	for _, locale := range Locales() {
+		for key := range messages[DefaultLocale] {
+			_, ok := messages[locale][key]
+			if !ok {
+				t.Errorf("locale %s is missing message %q", locale, key)
+			}
+		}
+
+		is.Equal(len(messages[locale]), len(messages[DefaultLocale]))
+	}
+}
+
+func TestLocale(t *testing.T) {
+	is := is.New(t)
+
+	is.Equal(Locale("es"), "es")
+	is.Equal(Locale("es-419"), "es")
+	is.Equal(Locale("ES_es"), "es")
+	is.Equal(Locale("en-US"), "en")
+	is.Equal(Locale("ja"), DefaultLocale)
+	is.Equal(Locale(""), DefaultLocale)
+}
+
+func TestT(t *testing.T) {
+	is := is.New(t)
+
+	t.Run("Translated message", func(t *testing.T) {
+		is.Equal(T("es", "subscriptions.removed"), "Suscripción eliminada")
+	})
+
+	t.Run("Formatted message", func(t *testing.T) {
+		msg := T("en", "rss.subscribed", "Naruto")
+		is.Equal(msg, "Succesfully subscribed to <b>Naruto</b>")
+	})
+
+	t.Run("Unsupported locale", func(t *testing.T) {
+		is.Equal(T("ja", "setfeed.changed"), "Feed changed")
+	})
+
+	t.Run("Missing message", func(t *testing.T) {
+		is.Equal(T("en", "missing.key"), "missing.key")
+	})
+}
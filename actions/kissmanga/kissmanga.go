@@ -81,8 +81,9 @@ func (k *Kissmanga) QueryManga(name string) *models.ApiQuerySuggestions {
 
 		mangaURL, _ := s.Attr("href")
 		manga := models.MangaSuggestions{
-			Data:  mangaURL,
-			Value: s.Text(),
+			Data:     mangaURL,
+			Value:    s.Text(),
+			Language: "en",
 		}
 
 		suggestions.Suggestions = append(suggestions.Suggestions, manga)
@@ -95,21 +96,35 @@ func (k *Kissmanga) QueryManga(name string) *models.ApiQuerySuggestions {
 // the last chapter published in this URL. An error might be returned if
 // no URL is supplied or if it cannot connect to the URL
 func (k *Kissmanga) GetLastMangaChapter(mangaURL string) (string, error) {
+
+	chapters, err := k.GetChapters(mangaURL)
+	if err != nil || len(chapters) == 0 {
+		return "", err
+	}
+
+	return chapters[0].URL, nil
+}
+
+// GetChapters method receives the URL to a manga title and returns the
+// chapters listed in its page, newest first. An error might be returned
+// if it cannot connect to the URL
+func (k *Kissmanga) GetChapters(mangaURL string) ([]models.MangaChapter, error) {
+
 	if mangaURL == "" {
 		log.Println("No manga supplied")
-		return "", nil
+		return nil, nil
 	}
 
 	req, err := http.NewRequest("GET", mangaURL, nil)
 	if err != nil {
 		log.Println("There was an error requesting Kissmanga page: ", err)
-		return "", err
+		return nil, err
 	}
 
 	res, err := http.DefaultClient.Do(req)
 	if err != nil {
 		log.Println("Error calling HTTP URL: ", err)
-		return "", err
+		return nil, err
 	}
 
 	defer res.Body.Close()
@@ -117,12 +132,28 @@ func (k *Kissmanga) GetLastMangaChapter(mangaURL string) (string, error) {
 	page, err := goquery.NewDocumentFromReader(res.Body)
 	if err != nil {
 		log.Println("there was an error getting the manga page: ", err)
-		return "", err
+		return nil, err
 	}
 
-	lastChapter, _ := page.Find("div.listing div div h3 a").First().Attr("href")
+	chapters := make([]models.MangaChapter, 0)
+	page.Find("div.listing div div h3 a").Each(func(idx int, s *goquery.Selection) {
+		href, ok := s.Attr("href")
+		if !ok {
+			return
+		}
+
+		if !strings.HasPrefix(href, "http") {
+			href = fmt.Sprintf(k.ViewMangaURL, href)
+		}
+
+		chapters = append(chapters, models.MangaChapter{
+			Title:    strings.Join(strings.Fields(s.Text()), " "),
+			URL:      href,
+			Language: "en",
+		})
+	})
 
-	return fmt.Sprintf(k.ViewMangaURL, lastChapter), nil
+	return chapters, nil
 }
 
 // Subscribe method receives a subscription model, this contains information
@@ -143,7 +174,7 @@ func (k *Kissmanga) Subscribe(subscription *models.Subscription) error {
 	}
 
 	subscription.ID = primitive.NewObjectID()
-	subscription.MangaFeed = 4
+	subscription.MangaFeed = models.FeedKissmanga
 
 	subscription.LastChapterURL, _ = k.GetLastMangaChapter(subscription.MangaURL)
 
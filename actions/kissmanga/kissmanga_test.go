@@ -87,6 +87,21 @@ func TestGetLastMangaChapter(t *testing.T) {
 	})
 }
 
+func TestGetChapters(t *testing.T) {
+	is := is.New(t)
+
+	kiss := NewKissmanga(nil)
+	server := testKissMangaReadServer()
+	defer server.Close()
+
+	chapters, err := kiss.GetChapters(server.URL)
+	is.NoErr(err)
+	is.True(len(chapters) > 1)
+	is.Equal(chapters[0].Title, "Naruto - Chapter 700.5 : Uzumaki Naruto")
+	is.Equal(chapters[0].URL, "https://kissmanga.org/chapter/manga-ng952689/chapter-700.5")
+	is.Equal(chapters[1].URL, "https://kissmanga.org/chapter/manga-ng952689/chapter-700.1")
+}
+
 func TestSubscribe(t *testing.T) {
 	is := is.New(t)
 	opts := &mtest.Options{}
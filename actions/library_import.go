@@ -0,0 +1,135 @@
+package actions
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/tavomoya/mangagram/actions/tachiyomi"
+	"github.com/tavomoya/mangagram/models"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// MaxLibraryEntries is the most titles of a library imported at
+// once. Every title might be searched in all the feeds.
+const MaxLibraryEntries = 100
+
+// minLibraryMatch is how similar the title found in a feed has
+// to be to the one of a library entry to be subscribed to.
+const minLibraryMatch = 0.9
+
+// libraryFeed is a feed library entries are searched in.
+type libraryFeed struct {
+	code int
+	feed MangaFeedInterface
+}
+
+// ImportLibrary method subscribes a Chat to the titles of a Tachiyomi
+// library, on behalf of a user. Backups don't say which of our feeds a
+// title is in, so every title is searched in the chat's feed first and
+// then in the other available ones, and the first result similar enough
+// to it is subscribed to. Titles the chat already has, and the ones
+// repeated in the library, are skipped. Only the first entries of big
+// libraries are imported. It might return an error if the chat's
+// subscriptions can't be queried.
+func ImportLibrary(db *models.DatabaseConfig, chatID int64, user *tb.User, library []tachiyomi.Manga) (*ImportResult, error) {
+
+	settings := GetChatSettings(db, chatID)
+
+	existing, err := GetChatSubscriptions(db, chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	for _, s := range existing {
+		seen[s.MangaURL] = true
+	}
+
+	feeds := []libraryFeed{}
+	for _, f := range append([]models.MangaFeed{{Code: settings.Feed}}, AvailableFeeds...) {
+		if f.Code == settings.Feed && len(feeds) > 0 {
+			continue
+		}
+
+		if feed := NewMangaInterface(f.Code, db); feed != nil {
+			SetFeedLanguages(feed, settings.Languages)
+			feeds = append(feeds, libraryFeed{f.Code, feed})
+		}
+	}
+
+	if len(library) > MaxLibraryEntries {
+		library = library[:MaxLibraryEntries]
+	}
+
+	titles := map[string]bool{}
+	result := &ImportResult{}
+	for _, entry := range library {
+		normalized := NormalizeTitle(entry.Title)
+		if titles[normalized] {
+			result.Duplicates++
+			continue
+		}
+		titles[normalized] = true
+
+		found, suggestion := matchLibraryEntry(db, feeds, normalized, entry.Title)
+		if found == nil {
+			result.Unmatched = append(result.Unmatched, entry.Title)
+			continue
+		}
+
+		mangaURL := fmt.Sprintf(found.feed.ViewManga(), suggestion.Data)
+		if seen[mangaURL] {
+			result.Duplicates++
+			continue
+		}
+		seen[mangaURL] = true
+
+		sub := &models.Subscription{
+			UserID:    user.ID,
+			UserName:  user.FirstName,
+			ChatID:    chatID,
+			MangaName: suggestion.Value,
+			MangaURL:  mangaURL,
+			MangaFeed: found.code,
+		}
+
+		_, err := SubscribeManga(db, found.feed, sub)
+		if err == ErrAlreadySubscribed {
+			result.Duplicates++
+			continue
+		}
+		if err != nil {
+			log.Println("There was an error importing the library entry: ", err)
+			result.Failed = append(result.Failed, entry.Title)
+			continue
+		}
+
+		result.Imported++
+		result.Matched = append(result.Matched, fmt.Sprintf("%s (%s)", suggestion.Value, FeedName(found.code)))
+	}
+
+	return result, nil
+}
+
+// matchLibraryEntry searches the feeds, in order, for the title of a
+// library entry and returns the first feed whose best result is close
+// enough to the normalized title, and that result. It returns nil if
+// no feed has the title.
+func matchLibraryEntry(db *models.DatabaseConfig, feeds []libraryFeed, normalized, title string) (*libraryFeed, *models.MangaSuggestions) {
+	for i, f := range feeds {
+		res := SearchManga(db, f.feed, title)
+		if res == nil || len(res.Suggestions) == 0 {
+			continue
+		}
+
+		// The whole titles are compared, so spin-offs
+		// like "One Piece Party" don't match
+		best := res.Suggestions[0]
+		if editSimilarity(normalized, NormalizeTitle(best.Value)) >= minLibraryMatch {
+			return &feeds[i], &best
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,40 @@
+package actions
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/tavomoya/mangagram/models"
+)
+
+func TestMatchLibraryEntry(t *testing.T) {
+	is := is.New(t)
+
+	chatFeed := &testQueryFeed{results: map[string][]models.MangaSuggestions{
+		"One Piece": {{Data: "one_piece_party", Value: "One Piece Party"}},
+	}}
+	otherFeed := &testQueryFeed{results: map[string][]models.MangaSuggestions{
+		"One Piece": {
+			{Data: "op_party", Value: "One Piece Party"},
+			{Data: "op", Value: "One Piece"},
+		},
+		"Naruto": {{Data: "naruto", Value: "NARUTO"}},
+	}}
+
+	feeds := []libraryFeed{{2, chatFeed}, {5, otherFeed}}
+
+	// Spin-offs are not the title
+	feed, found := matchLibraryEntry(nil, feeds, "one piece", "One Piece")
+	is.Equal(feed.code, 5)
+	is.Equal(found.Data, "op")
+
+	// The chat's feed is searched first
+	chatFeed.results["Naruto"] = []models.MangaSuggestions{{Data: "naruto-1", Value: "Naruto"}}
+	feed, found = matchLibraryEntry(nil, feeds, "naruto", "Naruto")
+	is.Equal(feed.code, 2)
+	is.Equal(found.Data, "naruto-1")
+
+	feed, found = matchLibraryEntry(nil, feeds, "berserk", "Berserk")
+	is.True(feed == nil)
+	is.True(found == nil)
+}
@@ -1,33 +1,87 @@
 package actions
 
-import "github.com/tavomoya/mangagram/models"
+import (
+	"fmt"
+
+	"github.com/tavomoya/mangagram/actions/selector"
+	"github.com/tavomoya/mangagram/models"
+)
 
 // AvailableFeeds defines information for
 // all current available manga feeds.
 var AvailableFeeds = []models.MangaFeed{
 	{
-		Code: 1,
+		Code: models.FeedMangaReader,
 		Name: "Manga Reader",
 		URL:  "http://manga-reader.fun",
 	},
 	{
-		Code: 2,
+		Code: models.FeedManganelo,
 		Name: "Manganelo",
 		URL:  "https://manganelo.com",
 	},
 	{
-		Code: 3,
+		Code: models.FeedMangaEden,
 		Name: "Manga Eden",
 		URL:  "https://mangaeden.com",
 	},
 	{
-		Code: 4,
+		Code: models.FeedKissmanga,
 		Name: "Kissmanga",
 		URL:  "https://kissmanga.org",
 	},
 	{
-		Code: 5,
+		Code: models.FeedMangadex,
 		Name: "Mangadex",
 		URL:  "https://mangadex.org",
 	},
+	{
+		Code: models.FeedWebtoons,
+		Name: "Webtoons",
+		URL:  "https://www.webtoons.com",
+	},
+}
+
+// selectorFeeds holds the config of every
+// selector feed registered, by feed code.
+var selectorFeeds = map[int]models.SelectorFeedConfig{}
+
+// RegisterSelectorFeeds function adds config-defined selector feeds
+// to the AvailableFeeds list so they can be used like the built-in ones.
+// It returns an error if a feed is invalid or its code is already taken.
+func RegisterSelectorFeeds(feeds []models.SelectorFeedConfig) error {
+
+	for _, f := range feeds {
+		err := selector.Validate(f)
+		if err != nil {
+			return err
+		}
+
+		for _, a := range AvailableFeeds {
+			if a.Code == f.Code {
+				return fmt.Errorf("feed code %d is already used by %s", f.Code, a.Name)
+			}
+		}
+
+		selectorFeeds[f.Code] = f
+		AvailableFeeds = append(AvailableFeeds, models.MangaFeed{
+			Code: f.Code,
+			Name: f.Name,
+			URL:  f.URL,
+		})
+	}
+
+	return nil
+}
+
+// FeedName returns the name of the feed with the given
+// code, or the code itself if it's not an available feed.
+func FeedName(code int) string {
+	for _, f := range AvailableFeeds {
+		if f.Code == code {
+			return f.Name
+		}
+	}
+
+	return fmt.Sprint(code)
 }
@@ -0,0 +1,320 @@
+package actions
+
+import (
+	"errors"
+	"html"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/tavomoya/mangagram/actions/i18n"
+	"github.com/tavomoya/mangagram/actions/rss"
+	"github.com/tavomoya/mangagram/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MangaInfoTTL is how long the info of a manga
+// title is cached before it's fetched again.
+const MangaInfoTTL = 24 * time.Hour
+
+// maxAltTitles is the maximum number of
+// alternative titles shown in a card.
+const maxAltTitles = 3
+
+// maxInfoChapters is the maximum number of latest chapters
+// kept in the info of a manga title, used to count the
+// chapters users haven't read.
+const maxInfoChapters = 100
+
+// maxCardChapters is the maximum number of
+// latest chapters shown in a card.
+const maxCardChapters = 3
+
+// GetMangaInfo method returns the info of a manga title from a feed. The
+// info is cached in the 'manga_info' collection and fetched again from
+// the feed once it's older than MangaInfoTTL; if that fails the cached
+// info is returned anyway. It returns an error if the feed doesn't exist
+// or the info can't be fetched and there's none cached.
+func GetMangaInfo(db *models.DatabaseConfig, feedCode int, mangaURL string) (*models.MangaInfo, error) {
+
+	if db == nil {
+		log.Println("The DB model is nil")
+		return nil, errors.New("the DB model passed is nil, can't operate")
+	}
+
+	if mangaURL == "" {
+		log.Println("No manga supplied")
+		return nil, errors.New("no manga supplied")
+	}
+
+	cached := CachedMangaInfo(db, mangaURL)
+	if cached != nil && time.Since(cached.UpdatedAt) < MangaInfoTTL {
+		return cached, nil
+	}
+
+	return fetchMangaInfo(db, feedCode, mangaURL, cached)
+}
+
+// RefreshMangaInfo method fetches the info of a manga title from a feed
+// and caches it, however recent the cached info is, like when the title
+// has a new chapter. See GetMangaInfo.
+func RefreshMangaInfo(db *models.DatabaseConfig, feedCode int, mangaURL string) (*models.MangaInfo, error) {
+
+	if db == nil {
+		log.Println("The DB model is nil")
+		return nil, errors.New("the DB model passed is nil, can't operate")
+	}
+
+	if mangaURL == "" {
+		log.Println("No manga supplied")
+		return nil, errors.New("no manga supplied")
+	}
+
+	return fetchMangaInfo(db, feedCode, mangaURL, CachedMangaInfo(db, mangaURL))
+}
+
+// fetchMangaInfo fetches the info of a manga title from a feed and caches
+// it. The cached info is returned if it can't be fetched.
+func fetchMangaInfo(db *models.DatabaseConfig, feedCode int, mangaURL string, cached *models.MangaInfo) (*models.MangaInfo, error) {
+	feed := NewMangaInterface(feedCode, db)
+	if feed == nil {
+		log.Println("Unknown manga feed: ", feedCode)
+		return nil, errors.New("unknown manga feed")
+	}
+
+	info, err := FetchMangaInfo(feed, mangaURL)
+	if err != nil || info == nil {
+		if cached != nil {
+			return cached, nil
+		}
+
+		if err == nil {
+			err = errors.New("no info available for this manga")
+		}
+
+		return nil, err
+	}
+
+	info.URL = mangaURL
+	info.Feed = feedCode
+	info.UpdatedAt = time.Now()
+
+	_, err = db.MongoClient.Collection("manga_info").ReplaceOne(
+		db.Ctx,
+		bson.M{"url": mangaURL},
+		info,
+		options.Replace().SetUpsert(true),
+	)
+	if err != nil {
+		log.Println("There was an error caching the manga info: ", err)
+	}
+
+	// The info's alt titles are shared with the manga series,
+	// so the title can be found by any of them
+	if info.Title != "" {
+		_, err = ResolveManga(db, feedCode, mangaURL, info.Title, info.AltTitles)
+		if err != nil {
+			log.Println("There was an error linking the manga info to its series: ", err)
+		}
+	}
+
+	return info, nil
+}
+
+// CachedMangaInfo function returns the cached info of a manga title, however
+// old it is, or nil if it has never been fetched.
+func CachedMangaInfo(db *models.DatabaseConfig, mangaURL string) *models.MangaInfo {
+	if db == nil || mangaURL == "" {
+		return nil
+	}
+
+	info := new(models.MangaInfo)
+	res := db.MongoClient.Collection("manga_info").FindOne(db.Ctx, bson.M{"url": mangaURL})
+	err := res.Decode(info)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Println("There was an error decoding the cached manga info: ", err)
+		}
+		return nil
+	}
+
+	return info
+}
+
+// FetchMangaInfo function gets the info of a manga title from its feed.
+// Feeds that don't implement MangaInfoInterface get it from the title's
+// page metadata. The chapters are listed by feeds that implement
+// MangaChaptersInterface, or else only the last one is filled in, if
+// the info has none. Only the latest maxInfoChapters chapters are kept. It
+// returns nil for RSS feeds, which don't describe a title.
+func FetchMangaInfo(feed MangaFeedInterface, mangaURL string) (*models.MangaInfo, error) {
+	var info *models.MangaInfo
+	var err error
+
+	switch f := feed.(type) {
+	case MangaInfoInterface:
+		info, err = f.GetMangaInfo(mangaURL)
+	case *rss.RSSFeed:
+		// RSS subscriptions point to the feed, not to a page
+		return nil, nil
+	default:
+		info, err = PageInfo(mangaURL)
+	}
+
+	if err != nil || info == nil {
+		return nil, err
+	}
+
+	if f, ok := feed.(MangaChaptersInterface); ok && len(info.Chapters) == 0 {
+		chapters, err := f.GetChapters(mangaURL)
+		if err != nil {
+			log.Println("There was an error getting the chapters: ", err)
+		}
+
+		for _, c := range chapters {
+			if c.Number == "" {
+				c.Number = ChapterNumber(c.Title)
+			}
+			info.Chapters = append(info.Chapters, c)
+		}
+	}
+
+	if len(info.Chapters) == 0 {
+		last, err := LastChapter(feed, mangaURL)
+		if err != nil {
+			log.Println("There was an error getting the last chapter: ", err)
+		}
+
+		if last != nil {
+			info.Chapters = []models.MangaChapter{*last}
+		}
+	}
+
+	if len(info.Chapters) > maxInfoChapters {
+		info.Chapters = info.Chapters[:maxInfoChapters]
+	}
+
+	return info, nil
+}
+
+// PageInfo function loads the page of a manga title and returns
+// its title, cover and description, taken from the page's
+// metadata. It returns an error if the page cannot be loaded.
+func PageInfo(pageURL string) (*models.MangaInfo, error) {
+	if pageURL == "" {
+		return nil, errors.New("no page supplied")
+	}
+
+	page, err := loadPage(pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &models.MangaInfo{
+		URL:   pageURL,
+		Cover: pageCover(page, pageURL),
+	}
+
+	info.Title, _ = page.Find(`meta[property="og:title"]`).Attr("content")
+	if info.Title == "" {
+		info.Title = page.Find("title").First().Text()
+	}
+	info.Title = strings.TrimSpace(info.Title)
+
+	for _, sel := range []string{`meta[property="og:description"]`, `meta[name="description"]`} {
+		if d, ok := page.Find(sel).First().Attr("content"); ok && strings.TrimSpace(d) != "" {
+			info.Description = strings.TrimSpace(d)
+			break
+		}
+	}
+
+	return info, nil
+}
+
+// MangaInfoCard function formats the info of a manga title as an HTML
+// message in the given locale. The description is shortened so the
+// whole card is at most limit characters long, e.g. to fit in a
+// photo's caption.
+func MangaInfoCard(locale string, info *models.MangaInfo, limit int) string {
+	lines := []string{"<b>" + html.EscapeString(info.Title) + "</b>"}
+
+	if len(info.AltTitles) > 0 {
+		alt := info.AltTitles
+		if len(alt) > maxAltTitles {
+			alt = alt[:maxAltTitles]
+		}
+		lines = append(lines, "<i>"+html.EscapeString(strings.Join(alt, " · "))+"</i>")
+	}
+
+	lines = append(lines, "")
+
+	if info.Author != "" {
+		lines = append(lines, i18n.T(locale, "info.author", html.EscapeString(info.Author)))
+	}
+
+	if info.Status != "" {
+		lines = append(lines, i18n.T(locale, "info.status", html.EscapeString(info.Status)))
+	}
+
+	if len(info.Genres) > 0 {
+		lines = append(lines, i18n.T(locale, "info.genres", html.EscapeString(strings.Join(info.Genres, ", "))))
+	}
+
+	chapters := info.Chapters
+	if len(chapters) > maxCardChapters {
+		chapters = chapters[:maxCardChapters]
+	}
+
+	if len(chapters) > 0 {
+		lines = append(lines, i18n.T(locale, "info.chapters"))
+	}
+
+	for _, c := range chapters {
+		name := c.Title
+		if name == "" {
+			name = c.Number
+		}
+		if name == "" {
+			name = c.URL
+		}
+
+		lines = append(lines, `• <a href="`+html.EscapeString(c.URL)+`">`+html.EscapeString(name)+"</a>")
+	}
+
+	card := strings.TrimSpace(strings.Join(lines, "\n"))
+
+	// Whatever room is left goes to the description
+	room := limit - utf8.RuneCountInString(card) - 2
+	if info.Description == "" || room < 20 {
+		return card
+	}
+
+	return card + "\n\n" + shortenHTML(info.Description, room)
+}
+
+// shortenHTML escapes a text for an HTML message, shortening
+// it with an ellipsis to at most max characters once escaped.
+func shortenHTML(text string, max int) string {
+	escaped := html.EscapeString(text)
+	if utf8.RuneCountInString(escaped) <= max {
+		return escaped
+	}
+
+	runes := []rune(text)
+	if len(runes) >= max {
+		runes = runes[:max-1]
+	}
+
+	for {
+		escaped = html.EscapeString(strings.TrimSpace(string(runes))) + "…"
+		if len(runes) == 0 || utf8.RuneCountInString(escaped) <= max {
+			return escaped
+		}
+
+		runes = runes[:len(runes)-1]
+	}
+}
@@ -0,0 +1,155 @@
+package actions
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/matryer/is"
+	"github.com/tavomoya/mangagram/actions/mangaeden"
+	"github.com/tavomoya/mangagram/actions/rss"
+	"github.com/tavomoya/mangagram/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestGetMangaInfo(t *testing.T) {
+	opts := &mtest.Options{}
+	opts.ClientType(mtest.Mock)
+	opts.CollectionName("manga_info")
+	opts.DatabaseName("mangagram")
+	opts.ShareClient(true)
+
+	mt := mtest.New(t, opts)
+	defer mt.Close()
+
+	is := is.New(t)
+	config := &models.DatabaseConfig{
+		Ctx:         context.Background(),
+		MongoClient: mt.Client.Database("mangagram"),
+	}
+
+	mt.Run("Nil Database", func(t *mtest.T) {
+		info, err := GetMangaInfo(nil, 2, "https://manganelo.com/manga/tokyo_ghoul")
+		is.True(info == nil)
+		is.True(err != nil)
+	})
+
+	mt.Run("No manga URL", func(t *mtest.T) {
+		info, err := GetMangaInfo(config, 2, "")
+		is.True(info == nil)
+		is.True(err != nil)
+	})
+
+	mt.Run("Cached info", func(t *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "manga_info.url", mtest.FirstBatch, bson.D{
+			{Key: "url", Value: "https://manganelo.com/manga/tokyo_ghoul"},
+			{Key: "feed", Value: 2},
+			{Key: "title", Value: "Tokyo Ghoul"},
+			{Key: "author", Value: "Ishida Sui"},
+			{Key: "updatedat", Value: time.Now().Add(-time.Hour)},
+		}))
+
+		info, err := GetMangaInfo(config, 2, "https://manganelo.com/manga/tokyo_ghoul")
+		is.NoErr(err)
+		is.Equal(info.Title, "Tokyo Ghoul")
+		is.Equal(info.Author, "Ishida Sui")
+	})
+
+	mt.Run("Unknown feed", func(t *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "manga_info.url", mtest.FirstBatch))
+
+		info, err := GetMangaInfo(config, 99, "https://example.com/manga/tokyo_ghoul")
+		is.True(info == nil)
+		is.True(err != nil)
+	})
+}
+
+func TestFetchMangaInfo(t *testing.T) {
+	is := is.New(t)
+
+	info, err := FetchMangaInfo(rss.NewRSSFeed(nil), "https://example.com/feed.xml")
+	is.NoErr(err)
+	is.True(info == nil)
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		file, _ := ioutil.ReadFile("./../test/mangaeden-reader.html")
+		rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+		rw.Write(file)
+	}))
+	defer server.Close()
+
+	// Feeds that list chapters have more than the last one
+	info, err = FetchMangaInfo(mangaeden.NewMangaeden(nil), server.URL)
+	is.NoErr(err)
+	is.Equal(len(info.Chapters), maxInfoChapters)
+	is.Equal(info.Chapters[0].Number, "279")
+	is.Equal(info.Chapters[1].URL, "https://www.mangaeden.com/en/en-manga/boku-no-hero-academia/278/1/")
+}
+
+func TestPageInfo(t *testing.T) {
+	is := is.New(t)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/manga" {
+			rw.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		rw.Write([]byte(`<html><head>
+			<title>Ignored</title>
+			<meta property="og:title" content="One Piece">
+			<meta property="og:image" content="/covers/one-piece.jpg">
+			<meta name="description" content="Gol D. Roger was known as the Pirate King.">
+		</head></html>`))
+	}))
+	defer server.Close()
+
+	t.Run("Page not found", func(t *testing.T) {
+		info, err := PageInfo(server.URL + "/missing")
+		is.True(info == nil)
+		is.True(err != nil)
+	})
+
+	t.Run("Page metadata", func(t *testing.T) {
+		info, err := PageInfo(server.URL + "/manga")
+		is.NoErr(err)
+		is.Equal(info.Title, "One Piece")
+		is.Equal(info.Cover, server.URL+"/covers/one-piece.jpg")
+		is.Equal(info.Description, "Gol D. Roger was known as the Pirate King.")
+	})
+}
+
+func TestMangaInfoCard(t *testing.T) {
+	is := is.New(t)
+	info := &models.MangaInfo{
+		Title:     "Tokyo Ghoul",
+		AltTitles: []string{"東京喰種", "Toukyou Kushu", "Tokyo Kushu", "Tokyo Kusyu"},
+		Author:    "Ishida Sui",
+		Status:    "Completed",
+		Genres:    []string{"Action", "Horror"},
+		Chapters: []models.MangaChapter{
+			{Title: "Chapter 145", URL: "https://manganelo.com/chapter/tokyo_ghoul/chapter_145"},
+			{Number: "144", URL: "https://manganelo.com/chapter/tokyo_ghoul/chapter_144"},
+		},
+		Description: "Ghouls & humans <live> together. " + strings.Repeat("Kaneki fights. ", 100),
+	}
+
+	card := MangaInfoCard("en", info, maxCaptionLength)
+	is.True(strings.HasPrefix(card, "<b>Tokyo Ghoul</b>\n<i>東京喰種 · Toukyou Kushu · Tokyo Kushu</i>\n\n"))
+	is.True(strings.Contains(card, "Author: Ishida Sui\nStatus: Completed\nGenres: Action, Horror\n"))
+	is.True(strings.Contains(card, "Latest chapters:\n"+
+		`• <a href="https://manganelo.com/chapter/tokyo_ghoul/chapter_145">Chapter 145</a>`+"\n"+
+		`• <a href="https://manganelo.com/chapter/tokyo_ghoul/chapter_144">144</a>`))
+	is.True(strings.Contains(card, "Ghouls &amp; humans &lt;live&gt; together."))
+	is.True(strings.HasSuffix(card, "…"))
+	is.True(utf8.RuneCountInString(card) <= maxCaptionLength)
+
+	info.Description = "Short."
+	card = MangaInfoCard("en", info, maxCaptionLength)
+	is.True(strings.HasSuffix(card, "\n\nShort."))
+}
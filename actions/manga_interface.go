@@ -1,20 +1,30 @@
 package actions
 
 import (
+	"log"
+	"net/url"
+	"regexp"
+
 	"github.com/tavomoya/mangagram/actions/kissmanga"
 	"github.com/tavomoya/mangagram/actions/mangadex"
 	"github.com/tavomoya/mangagram/actions/mangaeden"
 	"github.com/tavomoya/mangagram/actions/manganelo"
 	"github.com/tavomoya/mangagram/actions/mangareader"
+	"github.com/tavomoya/mangagram/actions/rss"
+	"github.com/tavomoya/mangagram/actions/selector"
+	"github.com/tavomoya/mangagram/actions/webtoons"
 	"github.com/tavomoya/mangagram/models"
 )
 
-// Available Manga Feeds:
+// Available Manga Feeds, their codes are the models.Feed* constants:
 // 1- MangaReader (default)
 // 2- Manganelo
 // 3- MangaEden
 // 4- Kissmanga
 // 5- Mangadex
+// 6- RSS/Atom (only through /follow_rss, not listed in AvailableFeeds)
+// 7- Webtoons
+// 100+ Selector feeds loaded from the SELECTOR_FEEDS file
 
 // MangaFeedInterface defines the interface to all
 // methods in the different manga sources.
@@ -25,22 +35,165 @@ type MangaFeedInterface interface {
 	GetLastMangaChapter(string) (string, error)
 }
 
+// MangaLanguageInterface defines the methods implemented by
+// manga sources that publish chapters in more than one language.
+type MangaLanguageInterface interface {
+	SupportedLanguages() []string
+	SetLanguages([]string)
+}
+
+// MangaChapterInterface defines the methods implemented by manga
+// sources that know more about a chapter than its URL, like its
+// number and title.
+type MangaChapterInterface interface {
+	GetLastChapter(string) (*models.MangaChapter, error)
+}
+
+// MangaChaptersInterface defines the methods implemented by manga
+// sources that can list a title's chapters, newest first.
+type MangaChaptersInterface interface {
+	GetChapters(string) ([]models.MangaChapter, error)
+}
+
+// MangaCoverInterface defines the methods implemented by manga
+// sources that have a better way to get a title's cover than
+// looking for it in the title's page.
+type MangaCoverInterface interface {
+	GetMangaCover(string) (string, error)
+}
+
+// MangaInfoInterface defines the methods implemented by manga
+// sources that can describe a title better than the metadata
+// in its page, like its author, status and genres.
+type MangaInfoInterface interface {
+	GetMangaInfo(string) (*models.MangaInfo, error)
+}
+
+// chapterNumberRegex matches chapter numbers labeled as
+// such in titles and URLs, e.g. "Chapter 12" or "ep-3.5".
+var chapterNumberRegex = regexp.MustCompile(`(?i)\b(?:chapter|chap|ch|episode|ep)[\s._\-=/#]*(\d+(?:\.\d+)?)`)
+
+// trailingNumberRegex matches the last number of a string.
+var trailingNumberRegex = regexp.MustCompile(`(\d+(?:\.\d+)?)\D*$`)
+
+// DefaultLanguages are the chapter languages used by chats
+// that haven't chosen any, and the only language supported
+// by feeds that don't implement MangaLanguageInterface.
+var DefaultLanguages = []string{"en"}
+
+// FeedLanguages function returns the chapter languages
+// supported by a manga feed.
+func FeedLanguages(feed MangaFeedInterface) []string {
+	if f, ok := feed.(MangaLanguageInterface); ok {
+		return f.SupportedLanguages()
+	}
+
+	return DefaultLanguages
+}
+
+// SetFeedLanguages function sets the chapter languages a manga feed
+// uses for searches and new chapters. It does nothing for feeds
+// that only support the default languages.
+func SetFeedLanguages(feed MangaFeedInterface, langs []string) {
+	if f, ok := feed.(MangaLanguageInterface); ok && len(langs) > 0 {
+		f.SetLanguages(langs)
+	}
+}
+
+// LastChapter function returns the last chapter of a manga title.
+// Feeds that don't implement MangaChapterInterface only return the
+// chapter's URL, so its number is guessed from it. It returns nil
+// if the title has no chapters.
+func LastChapter(feed MangaFeedInterface, mangaURL string) (*models.MangaChapter, error) {
+	if f, ok := feed.(MangaChapterInterface); ok {
+		chapter, err := f.GetLastChapter(mangaURL)
+		if err == nil && chapter != nil && chapter.Number == "" {
+			chapter.Number = ChapterNumber(chapter.Title)
+		}
+
+		return chapter, err
+	}
+
+	last, err := feed.GetLastMangaChapter(mangaURL)
+	if err != nil || last == "" {
+		return nil, err
+	}
+
+	return &models.MangaChapter{
+		Number: ChapterNumber(last),
+		URL:    last,
+	}, nil
+}
+
+// MangaCover function returns the URL to the cover image of a manga
+// title. Feeds that don't implement MangaCoverInterface get it from
+// the title's page. It returns an empty string if there's no cover.
+func MangaCover(feed MangaFeedInterface, mangaURL string) string {
+	var cover string
+	var err error
+
+	switch f := feed.(type) {
+	case MangaCoverInterface:
+		cover, err = f.GetMangaCover(mangaURL)
+	case *rss.RSSFeed:
+		// RSS subscriptions point to the feed, not to a page
+		return ""
+	default:
+		cover, err = PageCover(mangaURL)
+	}
+
+	if err != nil {
+		log.Println("There was an error getting the manga cover: ", err)
+		return ""
+	}
+
+	return cover
+}
+
+// ChapterNumber function guesses the chapter number from a chapter's
+// title or URL, preferring numbers labeled as chapters or episodes.
+// For URLs the last number in the path is used otherwise. It
+// returns an empty string if there's no number.
+func ChapterNumber(s string) string {
+	if m := chapterNumberRegex.FindStringSubmatch(s); m != nil {
+		return m[1]
+	}
+
+	u, err := url.Parse(s)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+
+	if m := trailingNumberRegex.FindStringSubmatch(u.Path); m != nil {
+		return m[1]
+	}
+
+	return ""
+}
+
 // NewMangaInterface function creates a new MangaFeedInterface interface ready
 // to use.
 func NewMangaInterface(src int, db *models.DatabaseConfig) MangaFeedInterface {
 
 	switch src {
-	case 1:
+	case models.FeedMangaReader:
 		return mangareader.NewMangaReader(db)
-	case 2:
+	case models.FeedManganelo:
 		return manganelo.NewManganelo(db)
-	case 3:
+	case models.FeedMangaEden:
 		return mangaeden.NewMangaeden(db)
-	case 4:
+	case models.FeedKissmanga:
 		return kissmanga.NewKissmanga(db)
-	case 5:
+	case models.FeedMangadex:
 		return mangadex.NewMangadex(db)
+	case models.FeedRSS:
+		return rss.NewRSSFeed(db)
+	case models.FeedWebtoons:
+		return webtoons.NewWebtoons(db)
 	default:
+		if config, ok := selectorFeeds[src]; ok {
+			return selector.NewSelectorFeed(config, db)
+		}
 		return nil
 	}
 
@@ -2,20 +2,22 @@ package mangadex
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
-	"mime/multipart"
 	"net/http"
-	"net/http/cookiejar"
 	"net/url"
-	"os"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/tavomoya/mangagram/models"
 
-	"github.com/PuerkitoBio/goquery"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -25,20 +27,50 @@ import (
 type Mangadex struct {
 	DB           *models.DatabaseConfig
 	ApiURL       string
+	FeedURL      string
+	MangaURL     string
+	CoverURL     string
 	ViewMangaURL string
-	client       *http.Client
+	ChapterURL   string
+	LegacyURL    string
+	Languages    []string
+}
+
+// maxChapters is the most chapters of
+// a title requested by GetChapters.
+const maxChapters = 100
+
+// client is the HTTP client the API is called with,
+// which gives up on requests that take too long.
+var client = &http.Client{Timeout: 30 * time.Second}
+
+// legacyIDs caches the current IDs of the
+// titles of the retired v4 site, by their ID.
+var legacyIDs = struct {
+	sync.Mutex
+	m map[int]string
+}{m: map[int]string{}}
+
+// supportedLanguages lists the chapter languages
+// users can choose from on Mangadex.
+var supportedLanguages = []string{
+	"en", "es", "es-la", "pt-br", "fr", "it", "de", "ru",
+	"pl", "tr", "id", "vi", "ja", "ko", "zh",
 }
 
 // NewMangadex function returns a pointer to a Mangadex
 // struct that can be used to call all of its methods
 func NewMangadex(db *models.DatabaseConfig) *Mangadex {
-	jar, _ := cookiejar.New(nil)
-
 	return &Mangadex{
 		DB:           db,
-		ApiURL:       "https://mangadex.org/search?title=%s",
-		ViewMangaURL: "https://mangadex.org%s",
-		client:       &http.Client{Jar: jar},
+		ApiURL:       "https://api.mangadex.org/manga?title=%s&limit=10",
+		FeedURL:      "https://api.mangadex.org/manga/%s/feed?order[publishAt]=desc",
+		MangaURL:     "https://api.mangadex.org/manga/%s?includes[]=cover_art&includes[]=author",
+		CoverURL:     "https://uploads.mangadex.org/covers/%s/%s.512.jpg",
+		ViewMangaURL: "https://mangadex.org/title/%s",
+		ChapterURL:   "https://mangadex.org/chapter/%s",
+		LegacyURL:    "https://api.mangadex.org/legacy/mapping",
+		Languages:    []string{"en"},
 	}
 }
 
@@ -48,6 +80,31 @@ func (m *Mangadex) ViewManga() string {
 	return m.ViewMangaURL
 }
 
+// SupportedLanguages method returns the chapter
+// languages available on Mangadex
+func (m *Mangadex) SupportedLanguages() []string {
+	return supportedLanguages
+}
+
+// SetLanguages method sets the chapter languages used
+// to filter titles and chapters. Unsupported languages
+// are ignored and an empty list keeps the current ones.
+func (m *Mangadex) SetLanguages(langs []string) {
+	filtered := make([]string, 0)
+	for _, l := range langs {
+		for _, s := range supportedLanguages {
+			if l == s {
+				filtered = append(filtered, l)
+				break
+			}
+		}
+	}
+
+	if len(filtered) > 0 {
+		m.Languages = filtered
+	}
+}
+
 // QueryManga method receives a string that refers to the Manga name, it then
 // makes a call to the Mangadex API, and with the results it returns a
 // pointer to a ApiQuerySuggestions struct
@@ -57,91 +114,172 @@ func (m *Mangadex) QueryManga(name string) *models.ApiQuerySuggestions {
 		return nil
 	}
 
-	log.Println("Thename to query: ", name)
+	path := fmt.Sprintf(m.ApiURL, url.QueryEscape(name)) + m.languageQuery("availableTranslatedLanguage[]")
 
-	// Login
-	err := m.login()
+	body, err := m.get(path)
 	if err != nil {
+		log.Println("There was an error requesting Mangadex's API: ", err)
 		return nil
 	}
 
-	escapedName := url.PathEscape(name)
-
-	path := fmt.Sprintf(m.ApiURL, escapedName)
-	log.Println("the path: ", path)
-
-	req := m.getRequest(path)
-
-	res, err := m.client.Do(req)
-	if err != nil {
-		log.Println("Error getting to search path: ", err)
-		return nil
-	}
-
-	defer res.Body.Close()
-
-	page, err := goquery.NewDocumentFromReader(res.Body)
+	mangas := models.MangadexMangaListResponse{}
+	err = json.Unmarshal(body, &mangas)
 	if err != nil {
-		log.Println("There was an error getting to the search pahe: ", err)
+		log.Println("There was an error unmarshalling Mangadex's JSON response: ", err)
 		return nil
 	}
 
 	suggestions := new(models.ApiQuerySuggestions)
-	page.Find("div a.manga_title").Each(func(idx int, selection *goquery.Selection) {
-		mangaTitle, _ := selection.Attr("title")
-		mangaURL, _ := selection.Attr("href")
 
-		manga := models.MangaSuggestions{
-			Data:  mangaURL,
-			Value: mangaTitle,
+	for _, manga := range mangas.Data {
+		lang := m.language(manga)
+		if lang == "" {
+			continue
 		}
 
-		suggestions.Suggestions = append(suggestions.Suggestions, manga)
-	})
+		s := models.MangaSuggestions{
+			Data:     manga.ID,
+			Value:    m.title(manga),
+			Language: lang,
+		}
+
+		suggestions.Suggestions = append(suggestions.Suggestions, s)
+	}
 
 	return suggestions
 }
 
+// GetLastMangaChapter method receives the URL to a manga title and returns
+// the URL to the last chapter published in any of the feed's languages. An error
+// might be returned if the URL is not a Mangadex title or if the API
+// cannot be reached.
 func (m *Mangadex) GetLastMangaChapter(mangaURL string) (string, error) {
 
+	chapter, err := m.GetLastChapter(mangaURL)
+	if err != nil || chapter == nil {
+		return "", err
+	}
+
+	return chapter.URL, nil
+}
+
+// GetLastChapter method receives the URL to a manga title and returns the
+// last chapter published in any of the feed's languages, with its number
+// and title, or nil if there are none. An error might be returned if the
+// URL is not a Mangadex title or if the API cannot be reached.
+func (m *Mangadex) GetLastChapter(mangaURL string) (*models.MangaChapter, error) {
+
+	chapters, err := m.chapters(mangaURL, 1)
+	if err != nil || len(chapters) == 0 {
+		return nil, err
+	}
+
+	return &chapters[0], nil
+}
+
+// GetChapters method receives the URL to a manga title and returns its
+// latest maxChapters chapters in any of the feed's languages, newest
+// first. An error might be returned if the URL is not a Mangadex title
+// or if the API cannot be reached.
+func (m *Mangadex) GetChapters(mangaURL string) ([]models.MangaChapter, error) {
+	return m.chapters(mangaURL, maxChapters)
+}
+
+// chapters requests the latest chapters of a
+// title from its feed, at most limit of them.
+func (m *Mangadex) chapters(mangaURL string, limit int) ([]models.MangaChapter, error) {
+
 	if mangaURL == "" {
 		log.Println("No manga supplied")
-		return "", nil
+		return nil, nil
 	}
 
-	// Login
-	err := m.login()
+	id, err := m.id(mangaURL)
 	if err != nil {
-		return "", nil
+		return nil, err
 	}
 
-	req := m.getRequest(mangaURL)
+	body, err := m.get(fmt.Sprintf(m.FeedURL, id) + "&limit=" + strconv.Itoa(limit) + m.languageQuery("translatedLanguage[]"))
+	if err != nil {
+		log.Println("There was an error requesting the manga feed: ", err)
+		return nil, err
+	}
 
-	res, err := m.client.Do(req)
+	feed := models.MangadexChapterListResponse{}
+	err = json.Unmarshal(body, &feed)
 	if err != nil {
-		log.Println("Error getting to search path: ", err)
-		return "", nil
+		log.Println("There was an error unmarshalling Mangadex's JSON response: ", err)
+		return nil, err
 	}
 
-	defer res.Body.Close()
+	chapters := make([]models.MangaChapter, 0, len(feed.Data))
+	for _, c := range feed.Data {
+		chapters = append(chapters, models.MangaChapter{
+			Title:    c.Attributes.Title,
+			Number:   c.Attributes.Chapter,
+			URL:      fmt.Sprintf(m.ChapterURL, c.ID),
+			Language: c.Attributes.TranslatedLanguage,
+		})
+	}
+
+	return chapters, nil
+}
+
+// GetMangaCover method receives the URL to a manga title and returns
+// the URL to its cover image, or an empty string if it has none. An
+// error might be returned if the URL is not a Mangadex title or if
+// the API cannot be reached.
+func (m *Mangadex) GetMangaCover(mangaURL string) (string, error) {
 
-	page, err := goquery.NewDocumentFromReader(res.Body)
+	manga, err := m.getManga(mangaURL)
 	if err != nil {
-		log.Println("There was an error trying to get to the manga page: ", err)
 		return "", err
 	}
-	lastChapterURL := ""
-	page.Find("div.chapter-row").EachWithBreak(func(idx int, sel *goquery.Selection) bool {
 
-		lang, _ := sel.Attr("data-lang")
-		if lang == "1" {
-			lastChapterURL, _ = sel.Find("a.text-truncate").First().Attr("href")
-			return false
+	return m.cover(manga), nil
+}
+
+// GetMangaInfo method receives the URL to a manga title and returns its
+// title, cover, author, status, tags and description. An error might be
+// returned if the URL is not a Mangadex title or if the API cannot be
+// reached.
+func (m *Mangadex) GetMangaInfo(mangaURL string) (*models.MangaInfo, error) {
+
+	manga, err := m.getManga(mangaURL)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &models.MangaInfo{
+		URL:         mangaURL,
+		Feed:        models.FeedMangadex,
+		Title:       m.title(*manga),
+		Cover:       m.cover(manga),
+		Status:      strings.Title(manga.Attributes.Status),
+		Description: m.localized(manga.Attributes.Description),
+	}
+
+	for _, alt := range manga.Attributes.AltTitles {
+		for _, t := range alt {
+			info.AltTitles = append(info.AltTitles, t)
 		}
-		return true
-	})
+	}
 
-	return fmt.Sprintf(m.ViewMangaURL, lastChapterURL), nil
+	for _, tag := range manga.Attributes.Tags {
+		if name := m.localized(tag.Attributes.Name); name != "" {
+			info.Genres = append(info.Genres, name)
+		}
+	}
+
+	authors := []string{}
+	for _, r := range manga.Relationships {
+		if r.Type == "author" && r.Attributes.Name != "" {
+			authors = append(authors, r.Attributes.Name)
+		}
+	}
+	info.Author = strings.Join(authors, ", ")
+
+	return info, nil
 }
 
 // Subscribe method receives a subscription model, this contains information
@@ -153,16 +291,16 @@ func (m *Mangadex) Subscribe(subscription *models.Subscription) error {
 	// Validate subscription data
 	if subscription.MangaName == "" || subscription.MangaURL == "" {
 		log.Println("No manga supplied for subscription")
-		return errors.New("No manga supplied for subscription")
+		return errors.New("no manga supplied for subscription")
 	}
 
 	if subscription.ChatID == 0 {
 		log.Println("No Chat supplied for subscription")
-		return errors.New("No Chat supplied for subscription")
+		return errors.New("no Chat supplied for subscription")
 	}
 
 	subscription.ID = primitive.NewObjectID()
-	subscription.MangaFeed = 5
+	subscription.MangaFeed = models.FeedMangadex
 
 	subscription.LastChapterURL, _ = m.GetLastMangaChapter(subscription.MangaURL)
 
@@ -175,51 +313,242 @@ func (m *Mangadex) Subscribe(subscription *models.Subscription) error {
 	return nil
 }
 
-func (m *Mangadex) login() error {
+// title returns the manga title in the feed's first language,
+// falling back to english and then to any available title.
+func (m *Mangadex) title(manga models.MangadexManga) string {
+	return m.localized(manga.Attributes.Title)
+}
+
+// localized returns the text in the feed's first language, falling
+// back to english and then to the text of the first language code in
+// alphabetical order, so the same text is always chosen.
+func (m *Mangadex) localized(texts map[string]string) string {
+	if t, ok := texts[m.Languages[0]]; ok {
+		return t
+	}
+
+	if t, ok := texts["en"]; ok {
+		return t
+	}
+
+	langs := make([]string, 0, len(texts))
+	for l := range texts {
+		langs = append(langs, l)
+	}
+
+	if len(langs) == 0 {
+		return ""
+	}
+
+	sort.Strings(langs)
+
+	return texts[langs[0]]
+}
+
+// cover returns the URL to the cover art of a manga
+// requested with its cover_art relationship included,
+// or an empty string if it has none.
+func (m *Mangadex) cover(manga *models.MangadexManga) string {
+	for _, r := range manga.Relationships {
+		if r.Type == "cover_art" && r.Attributes.FileName != "" {
+			return fmt.Sprintf(m.CoverURL, manga.ID, r.Attributes.FileName)
+		}
+	}
 
-	loginURL := fmt.Sprintf(m.ViewMangaURL, "/ajax/actions.ajax.php?function=login")
-	username := os.Getenv("MANGADEX_USERNAME")
-	password := os.Getenv("MANGADEX_PASSWORD")
+	return ""
+}
 
-	data := &bytes.Buffer{}
-	writer := multipart.NewWriter(data)
-	writer.WriteField("login_username", username)
-	writer.WriteField("login_password", password)
+// language returns the first of the feed's languages the
+// manga has chapters in, or an empty string if there's none.
+// Mangas that don't report their languages are assumed to
+// be available in the feed's first language.
+func (m *Mangadex) language(manga models.MangadexManga) string {
+	available := manga.Attributes.AvailableTranslatedLanguages
+	if len(available) == 0 {
+		return m.Languages[0]
+	}
 
-	req, err := http.NewRequest("POST", loginURL, data)
+	for _, l := range m.Languages {
+		for _, a := range available {
+			if l == a {
+				return l
+			}
+		}
+	}
+
+	return ""
+}
+
+// languageQuery builds the query string used
+// to filter API results by the feed's languages.
+func (m *Mangadex) languageQuery(param string) string {
+	query := ""
+	for _, l := range m.Languages {
+		query += "&" + param + "=" + url.QueryEscape(l)
+	}
+
+	return query
+}
+
+// getManga requests a single manga, with its cover art
+// and authors, from the URL to its Mangadex title page.
+func (m *Mangadex) getManga(mangaURL string) (*models.MangadexManga, error) {
+	id, err := m.id(mangaURL)
 	if err != nil {
-		log.Println("Error creating login request: ", err)
-		return err
+		return nil, err
 	}
 
-	req.Header.Set("Content-Type", writer.FormDataContentType())
-	req.Header.Set("User-Agent", "mangadex-api/4.0.0")
-	req.Header.Set("X-Requested-With", "XMLHttpRequest")
+	body, err := m.get(fmt.Sprintf(m.MangaURL, id))
+	if err != nil {
+		log.Println("There was an error requesting the manga: ", err)
+		return nil, err
+	}
 
-	res, err := m.client.Do(req)
+	manga := models.MangadexMangaResponse{}
+	err = json.Unmarshal(body, &manga)
 	if err != nil {
-		log.Println("Error logging into Mangadex => ", err)
-		return err
+		log.Println("There was an error unmarshalling Mangadex's JSON response: ", err)
+		return nil, err
 	}
 
-	defer res.Body.Close()
+	if manga.Data.ID == "" {
+		manga.Data.ID = id
+	}
+
+	return &manga.Data, nil
+}
+
+// id returns the manga UUID of a Mangadex title URL. The numeric IDs of
+// the URLs of the retired v4 site are mapped to the current ones, and
+// the subscriptions to those URLs are moved to the current ones.
+func (m *Mangadex) id(mangaURL string) (string, error) {
+	id := mangaID(mangaURL)
+	if id == "" {
+		log.Println("Invalid Mangadex URL: ", mangaURL)
+		return "", errors.New("invalid Mangadex URL")
+	}
 
-	_, err = ioutil.ReadAll(res.Body)
+	legacy, err := strconv.Atoi(id)
 	if err != nil {
-		log.Println("Error reading mangadex response: ", err)
-		return err
+		return id, nil
 	}
 
-	return nil
+	id, err = m.legacyID(legacy)
+	if err != nil {
+		log.Println("There was an error mapping the legacy Mangadex ID: ", err)
+		return "", err
+	}
+
+	m.migrateLegacyURL(mangaURL, id)
+
+	return id, nil
 }
 
-func (m *Mangadex) getRequest(url string) *http.Request {
-	req, err := http.NewRequest("GET", url, nil)
+// legacyID returns the current UUID of a
+// title of the retired v4 site by its ID.
+func (m *Mangadex) legacyID(legacy int) (string, error) {
+	legacyIDs.Lock()
+	id, ok := legacyIDs.m[legacy]
+	legacyIDs.Unlock()
+
+	if ok {
+		return id, nil
+	}
+
+	query, _ := json.Marshal(map[string]interface{}{"type": "manga", "ids": []int{legacy}})
+
+	body, err := m.post(m.LegacyURL, query)
 	if err != nil {
-		log.Println("Error creating login request: ", err)
-		return nil
+		return "", err
+	}
+
+	mapping := models.MangadexLegacyMappingResponse{}
+	err = json.Unmarshal(body, &mapping)
+	if err != nil {
+		return "", err
+	}
+
+	for _, d := range mapping.Data {
+		if d.Attributes.LegacyID == legacy && d.Attributes.NewID != "" {
+			legacyIDs.Lock()
+			legacyIDs.m[legacy] = d.Attributes.NewID
+			legacyIDs.Unlock()
+
+			return d.Attributes.NewID, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown legacy Mangadex ID: %d", legacy)
+}
+
+// migrateLegacyURL moves the subscriptions to the URL
+// of a title of the retired v4 site to its current URL.
+func (m *Mangadex) migrateLegacyURL(mangaURL, id string) {
+	if m.DB == nil {
+		return
+	}
+
+	_, err := m.DB.MongoClient.Collection("subscription").UpdateMany(
+		m.DB.Ctx,
+		bson.M{"mangafeed": models.FeedMangadex, "mangaurl": mangaURL},
+		bson.M{"$set": bson.M{"mangaurl": fmt.Sprintf(m.ViewMangaURL, id)}},
+	)
+	if err != nil {
+		log.Println("There was an error moving the subscriptions to the new Mangadex URL: ", err)
+	}
+}
+
+func (m *Mangadex) get(path string) ([]byte, error) {
+	req, err := http.NewRequest("GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.do(req)
+}
+
+func (m *Mangadex) post(path string, body []byte) ([]byte, error) {
+	req, err := http.NewRequest("POST", path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	return m.do(req)
+}
+
+func (m *Mangadex) do(req *http.Request) ([]byte, error) {
+	req.Header.Set("User-Agent", "mangagram")
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status code was not OK: %d", res.StatusCode)
+	}
+
+	return ioutil.ReadAll(res.Body)
+}
+
+// mangaID extracts the manga ID from a Mangadex title URL
+// (https://mangadex.org/title/{id}/{slug}). It's a UUID, or
+// a number for the URLs of the retired v4 site.
+func mangaID(mangaURL string) string {
+	u, err := url.Parse(mangaURL)
+	if err != nil {
+		return ""
+	}
+
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i, p := range parts {
+		if p == "title" && i+1 < len(parts) {
+			return parts[i+1]
+		}
 	}
-	req.Header.Set("User-Agent", "mangadex-api/4.0.0")
 
-	return req
+	return ""
 }
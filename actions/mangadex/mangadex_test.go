@@ -0,0 +1,374 @@
+package mangadex
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/tavomoya/mangagram/models"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func testMangadexServer() *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/legacy/mapping" {
+			body, _ := ioutil.ReadAll(r.Body)
+			rw.Header().Set("Content-Type", "application/json")
+			rw.WriteHeader(http.StatusOK)
+
+			if strings.Contains(string(body), `"ids":[6]`) {
+				rw.Write([]byte(`{"result": "ok", "data": [{"attributes": {"type": "manga", "legacyId": 6, "newId": "6a1d1cb1-ecd5-40d9-89ff-9d88e40b136b"}}]}`))
+				return
+			}
+
+			rw.Write([]byte(`{"result": "ok", "data": []}`))
+			return
+		}
+
+		if strings.HasSuffix(r.URL.Path, "/feed") {
+			if strings.Contains(r.URL.Path, "empty") {
+				rw.Header().Set("Content-Type", "application/json")
+				rw.WriteHeader(http.StatusOK)
+				rw.Write([]byte(`{"result": "ok", "data": []}`))
+				return
+			}
+
+			file, _ := ioutil.ReadFile("./../../test/mangadex-feed.json")
+			rw.Header().Set("Content-Type", "application/json")
+			rw.WriteHeader(http.StatusOK)
+			rw.Write(file)
+			return
+		}
+
+		if strings.HasPrefix(r.URL.Path, "/manga/") {
+			file, _ := ioutil.ReadFile("./../../test/mangadex-manga.json")
+			rw.Header().Set("Content-Type", "application/json")
+			rw.WriteHeader(http.StatusOK)
+			rw.Write(file)
+			return
+		}
+
+		query := r.URL.Query().Get("title")
+
+		if query == "err" {
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if query == "badjson" {
+			rw.Header().Set("Content-Type", "application/json")
+			rw.WriteHeader(http.StatusOK)
+			rw.Write([]byte(`{"data": 4}`))
+			return
+		}
+
+		file, _ := ioutil.ReadFile("./../../test/mangadex-search.json")
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(http.StatusOK)
+		rw.Write(file)
+	}))
+
+	return server
+}
+
+func TestViewManga(t *testing.T) {
+	is := is.New(t)
+	manga := NewMangadex(nil)
+
+	url := manga.ViewManga()
+	is.Equal(url, manga.ViewMangaURL)
+}
+
+func TestQueryManga(t *testing.T) {
+	is := is.New(t)
+	manga := NewMangadex(nil)
+	server := testMangadexServer()
+	defer server.Close()
+
+	manga.ApiURL = server.URL + "/manga?title=%s"
+
+	t.Run("No manga name", func(t *testing.T) {
+		suggestions := manga.QueryManga("")
+		is.Equal(suggestions, nil)
+	})
+
+	t.Run("API error, non-200 response", func(t *testing.T) {
+		suggestions := manga.QueryManga("err")
+		is.Equal(suggestions, nil)
+	})
+
+	t.Run("Parsing error, incorrect JSON", func(t *testing.T) {
+		suggestions := manga.QueryManga("badjson")
+		is.Equal(suggestions, nil)
+	})
+
+	t.Run("Happy path", func(t *testing.T) {
+		suggestions := manga.QueryManga("tokyo ghoul")
+		is.True(suggestions != nil)
+		is.Equal(len(suggestions.Suggestions), 2)
+		is.Equal(suggestions.Suggestions[0].Value, "Tokyo Ghoul")
+		is.Equal(suggestions.Suggestions[0].Data, "6a1d1cb1-ecd5-40d9-89ff-9d88e40b136b")
+		is.Equal(suggestions.Suggestions[0].Language, "en")
+	})
+
+	t.Run("Titles in other languages", func(t *testing.T) {
+		manga.SetLanguages([]string{"fr", "es"})
+		defer manga.SetLanguages([]string{"en"})
+
+		suggestions := manga.QueryManga("tokyo ghoul")
+		is.True(suggestions != nil)
+		is.Equal(len(suggestions.Suggestions), 2)
+		is.Equal(suggestions.Suggestions[0].Language, "es")
+		is.Equal(suggestions.Suggestions[1].Value, "Tokyo Ghoul: Jack")
+		is.Equal(suggestions.Suggestions[1].Language, "fr")
+	})
+}
+
+func TestSetLanguages(t *testing.T) {
+	is := is.New(t)
+	manga := NewMangadex(nil)
+
+	manga.SetLanguages([]string{"xx"})
+	is.Equal(manga.Languages, []string{"en"})
+
+	manga.SetLanguages([]string{"es-la", "xx", "pt-br"})
+	is.Equal(manga.Languages, []string{"es-la", "pt-br"})
+}
+
+func TestGetLastMangaChapter(t *testing.T) {
+	is := is.New(t)
+	manga := NewMangadex(nil)
+	server := testMangadexServer()
+	defer server.Close()
+
+	manga.FeedURL = server.URL + "/manga/%s/feed?order[publishAt]=desc"
+
+	t.Run("No manga URL supplied", func(t *testing.T) {
+		url, err := manga.GetLastMangaChapter("")
+		is.Equal(url, "")
+		is.NoErr(err)
+	})
+
+	t.Run("Not a Mangadex title URL", func(t *testing.T) {
+		url, err := manga.GetLastMangaChapter("https://mangadex.org/search")
+		is.Equal(url, "")
+		is.True(err != nil)
+	})
+
+	t.Run("No chapters in the feed", func(t *testing.T) {
+		url, err := manga.GetLastMangaChapter("https://mangadex.org/title/empty")
+		is.Equal(url, "")
+		is.NoErr(err)
+	})
+
+	t.Run("Happy path", func(t *testing.T) {
+		expect := "https://mangadex.org/chapter/2f1b6e3a-1c2d-4e5f-8a9b-0c1d2e3f4a5b"
+		url, err := manga.GetLastMangaChapter("https://mangadex.org/title/6a1d1cb1-ecd5-40d9-89ff-9d88e40b136b/tokyo-ghoul")
+		is.Equal(url, expect)
+		is.NoErr(err)
+	})
+}
+
+func TestGetLastChapter(t *testing.T) {
+	is := is.New(t)
+	manga := NewMangadex(nil)
+	server := testMangadexServer()
+	defer server.Close()
+
+	manga.FeedURL = server.URL + "/manga/%s/feed?order[publishAt]=desc"
+
+	chapter, err := manga.GetLastChapter("https://mangadex.org/title/6a1d1cb1-ecd5-40d9-89ff-9d88e40b136b/tokyo-ghoul")
+	is.NoErr(err)
+	is.Equal(chapter.URL, "https://mangadex.org/chapter/2f1b6e3a-1c2d-4e5f-8a9b-0c1d2e3f4a5b")
+	is.Equal(chapter.Number, "143")
+	is.Equal(chapter.Title, "Bell")
+
+	chapter, err = manga.GetLastChapter("https://mangadex.org/title/empty")
+	is.NoErr(err)
+	is.True(chapter == nil)
+}
+
+func TestGetChapters(t *testing.T) {
+	is := is.New(t)
+	manga := NewMangadex(nil)
+
+	var query url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		file, _ := ioutil.ReadFile("./../../test/mangadex-feed.json")
+		rw.Header().Set("Content-Type", "application/json")
+		rw.Write(file)
+	}))
+	defer server.Close()
+
+	manga.FeedURL = server.URL + "/manga/%s/feed?order[publishAt]=desc"
+
+	chapters, err := manga.GetChapters("https://mangadex.org/title/6a1d1cb1-ecd5-40d9-89ff-9d88e40b136b/tokyo-ghoul")
+	is.NoErr(err)
+	is.Equal(query.Get("limit"), "100")
+	is.Equal(query["translatedLanguage[]"], []string{"en"})
+	is.Equal(chapters, []models.MangaChapter{{
+		Title:    "Bell",
+		Number:   "143",
+		URL:      "https://mangadex.org/chapter/2f1b6e3a-1c2d-4e5f-8a9b-0c1d2e3f4a5b",
+		Language: "en",
+	}})
+
+	_, err = manga.GetLastChapter("https://mangadex.org/title/6a1d1cb1-ecd5-40d9-89ff-9d88e40b136b/tokyo-ghoul")
+	is.NoErr(err)
+	is.Equal(query.Get("limit"), "1")
+}
+
+func TestLegacyURL(t *testing.T) {
+	is := is.New(t)
+	manga := NewMangadex(nil)
+	server := testMangadexServer()
+	defer server.Close()
+
+	manga.FeedURL = server.URL + "/manga/%s/feed?order[publishAt]=desc"
+	manga.LegacyURL = server.URL + "/legacy/mapping"
+
+	chapter, err := manga.GetLastChapter("https://mangadex.org/title/6/tokyo-ghoul")
+	is.NoErr(err)
+	is.Equal(chapter.URL, "https://mangadex.org/chapter/2f1b6e3a-1c2d-4e5f-8a9b-0c1d2e3f4a5b")
+
+	chapter, err = manga.GetLastChapter("https://mangadex.org/title/999")
+	is.True(err != nil)
+	is.True(chapter == nil)
+}
+
+func TestLocalized(t *testing.T) {
+	is := is.New(t)
+	manga := NewMangadex(nil)
+	manga.SetLanguages([]string{"es"})
+
+	is.Equal(manga.localized(map[string]string{"es": "Uno", "en": "One"}), "Uno")
+	is.Equal(manga.localized(map[string]string{"ja": "Ichi", "en": "One"}), "One")
+	is.Equal(manga.localized(map[string]string{"ja": "Ichi", "fr": "Un", "ko": "Hana"}), "Un")
+	is.Equal(manga.localized(nil), "")
+}
+
+func TestGetMangaCover(t *testing.T) {
+	is := is.New(t)
+	manga := NewMangadex(nil)
+	server := testMangadexServer()
+	defer server.Close()
+
+	manga.MangaURL = server.URL + "/manga/%s?includes[]=cover_art"
+
+	t.Run("Not a Mangadex title URL", func(t *testing.T) {
+		cover, err := manga.GetMangaCover("https://mangadex.org/search")
+		is.Equal(cover, "")
+		is.True(err != nil)
+	})
+
+	t.Run("Happy path", func(t *testing.T) {
+		expect := "https://uploads.mangadex.org/covers/6a1d1cb1-ecd5-40d9-89ff-9d88e40b136b/b3f1a2c4-5d6e-4f70-8a9b-c0d1e2f3a4b5.jpg.512.jpg"
+		cover, err := manga.GetMangaCover("https://mangadex.org/title/6a1d1cb1-ecd5-40d9-89ff-9d88e40b136b/tokyo-ghoul")
+		is.Equal(cover, expect)
+		is.NoErr(err)
+	})
+}
+
+func TestGetMangaInfo(t *testing.T) {
+	is := is.New(t)
+	manga := NewMangadex(nil)
+	server := testMangadexServer()
+	defer server.Close()
+
+	manga.MangaURL = server.URL + "/manga/%s?includes[]=cover_art&includes[]=author"
+
+	t.Run("Not a Mangadex title URL", func(t *testing.T) {
+		info, err := manga.GetMangaInfo("https://mangadex.org/search")
+		is.True(info == nil)
+		is.True(err != nil)
+	})
+
+	t.Run("Happy path", func(t *testing.T) {
+		info, err := manga.GetMangaInfo("https://mangadex.org/title/6a1d1cb1-ecd5-40d9-89ff-9d88e40b136b/tokyo-ghoul")
+		is.NoErr(err)
+		is.Equal(info.Feed, 5)
+		is.Equal(info.Title, "Tokyo Ghoul")
+		is.Equal(info.AltTitles, []string{"東京喰種トーキョーグール", "Tokyo Kushu"})
+		is.Equal(info.Author, "Ishida Sui")
+		is.Equal(info.Status, "Completed")
+		is.Equal(info.Genres, []string{"Action", "Horror"})
+		is.True(strings.HasPrefix(info.Description, "Ghouls live among us"))
+		is.Equal(info.Cover, "https://uploads.mangadex.org/covers/6a1d1cb1-ecd5-40d9-89ff-9d88e40b136b/b3f1a2c4-5d6e-4f70-8a9b-c0d1e2f3a4b5.jpg.512.jpg")
+	})
+}
+
+func TestSubscribe(t *testing.T) {
+	is := is.New(t)
+	opts := &mtest.Options{}
+	opts.ClientType(mtest.Mock)
+	opts.CollectionName("subscription")
+	opts.DatabaseName("mangagram")
+	opts.ShareClient(true)
+
+	mt := mtest.New(t, opts)
+	defer mt.Close()
+
+	manga := NewMangadex(&models.DatabaseConfig{
+		Ctx:         context.Background(),
+		MongoClient: mt.Client.Database("mangagram"),
+	})
+	server := testMangadexServer()
+	defer server.Close()
+
+	manga.FeedURL = server.URL + "/manga/%s/feed?order[publishAt]=desc"
+	mangaURL := "https://mangadex.org/title/6a1d1cb1-ecd5-40d9-89ff-9d88e40b136b"
+
+	t.Run("No manga name supplied", func(t *testing.T) {
+		sub := &models.Subscription{}
+		err := manga.Subscribe(sub)
+		is.True(err != nil)
+		is.True(strings.Contains(err.Error(), "no manga supplied"))
+	})
+
+	t.Run("No Chat ID supplied", func(t *testing.T) {
+		sub := &models.Subscription{
+			MangaName: "Tokyo Ghoul",
+			MangaURL:  mangaURL,
+		}
+		err := manga.Subscribe(sub)
+		is.True(err != nil)
+		is.True(strings.Contains(err.Error(), "no Chat supplied"))
+	})
+
+	t.Run("Error inserting subscription", func(t *testing.T) {
+		sub := &models.Subscription{
+			MangaName: "Tokyo Ghoul",
+			MangaURL:  mangaURL,
+			ChatID:    10,
+		}
+
+		mt.AddMockResponses(mtest.CreateWriteErrorsResponse(mtest.WriteError{
+			Code:    11000,
+			Message: "something went wrong",
+		}))
+
+		err := manga.Subscribe(sub)
+		is.True(err != nil)
+	})
+
+	t.Run("Happy path", func(t *testing.T) {
+		sub := &models.Subscription{
+			MangaName: "Tokyo Ghoul",
+			MangaURL:  mangaURL,
+			ChatID:    10,
+		}
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+
+		err := manga.Subscribe(sub)
+		is.NoErr(err)
+		is.Equal(sub.MangaFeed, 5)
+		is.Equal(sub.LastChapterURL, "https://mangadex.org/chapter/2f1b6e3a-1c2d-4e5f-8a9b-0c1d2e3f4a5b")
+	})
+}
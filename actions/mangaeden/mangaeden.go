@@ -8,6 +8,7 @@ import (
 	"log"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/tavomoya/mangagram/models"
@@ -23,6 +24,7 @@ type Mangaeden struct {
 	DB           *models.DatabaseConfig
 	ApiURL       string
 	ViewMangaURL string
+	Languages    []string
 }
 
 // NewMangaeden function returns a pointer to a Mangaeden
@@ -32,6 +34,7 @@ func NewMangaeden(db *models.DatabaseConfig) *Mangaeden {
 		DB:           db,
 		ApiURL:       "https://mangaeden.com/ajax/search-manga/?term=%s",
 		ViewMangaURL: "https://mangaeden.com%s",
+		Languages:    []string{"en"},
 	}
 }
 
@@ -41,6 +44,28 @@ func (m *Mangaeden) ViewManga() string {
 	return m.ViewMangaURL
 }
 
+// SupportedLanguages method returns the chapter
+// languages available on Mangaeden
+func (m *Mangaeden) SupportedLanguages() []string {
+	return []string{"en", "it"}
+}
+
+// SetLanguages method sets the languages used to filter
+// titles. Unsupported languages are ignored and an empty
+// list keeps the current ones.
+func (m *Mangaeden) SetLanguages(langs []string) {
+	filtered := make([]string, 0)
+	for _, l := range langs {
+		if l == "en" || l == "it" {
+			filtered = append(filtered, l)
+		}
+	}
+
+	if len(filtered) > 0 {
+		m.Languages = filtered
+	}
+}
+
 // QueryManga method receives a string that refers to the Manga name, it then
 // makes a call to the Mangaeden API, and with the results it returns a
 // pointer to a ApiQuerySuggestions struct
@@ -82,16 +107,21 @@ func (m *Mangaeden) QueryManga(name string) *models.ApiQuerySuggestions {
 
 	for _, manga := range mangas {
 
-		// Let's filter the mangas to only English
-		// Mangaeden returns both in english and Italian
-		// but I won't use the Italian versions. (yet)
+		// Mangaeden returns both english and italian
+		// titles, the URL tells them apart.
+		lang := "en"
 		if strings.Contains(manga.URL, "it-manga") {
+			lang = "it"
+		}
+
+		if !m.hasLanguage(lang) {
 			continue
 		}
 
 		s := models.MangaSuggestions{
-			Data:  manga.URL,
-			Value: manga.Value,
+			Data:     manga.URL,
+			Value:    manga.Value,
+			Language: lang,
 		}
 
 		suggestions.Suggestions = append(suggestions.Suggestions, s)
@@ -105,21 +135,34 @@ func (m *Mangaeden) QueryManga(name string) *models.ApiQuerySuggestions {
 // no URL is supplied or if it cannot connect to the URL
 func (m *Mangaeden) GetLastMangaChapter(mangaURL string) (string, error) {
 
+	chapters, err := m.GetChapters(mangaURL)
+	if err != nil || len(chapters) == 0 {
+		return "", err
+	}
+
+	return chapters[0].URL, nil
+}
+
+// GetChapters method receives the URL to a manga title and returns the
+// chapters listed in its page, newest first. An error might be returned
+// if it cannot connect to the URL
+func (m *Mangaeden) GetChapters(mangaURL string) ([]models.MangaChapter, error) {
+
 	if mangaURL == "" {
 		log.Println("No manga supplied")
-		return "", nil
+		return nil, nil
 	}
 
 	req, err := http.NewRequest("GET", mangaURL, nil)
 	if err != nil {
-		log.Println("There was an error requesting Kissmanga page: ", err)
-		return "", err
+		log.Println("There was an error requesting Mangaeden page: ", err)
+		return nil, err
 	}
 
 	res, err := http.DefaultClient.Do(req)
 	if err != nil {
 		log.Println("Error calling HTTP URL: ", err)
-		return "", err
+		return nil, err
 	}
 
 	defer res.Body.Close()
@@ -127,12 +170,41 @@ func (m *Mangaeden) GetLastMangaChapter(mangaURL string) (string, error) {
 	page, err := goquery.NewDocumentFromReader(res.Body)
 	if err != nil {
 		log.Println("There was an error getting the manga page: ", err)
-		return "", err
+		return nil, err
 	}
 
-	lastChapter, _ := page.Find("a.chapterLink").First().Attr("href")
+	chapters := make([]models.MangaChapter, 0)
+	page.Find("a.chapterLink").Each(func(idx int, s *goquery.Selection) {
+		href, ok := s.Attr("href")
+		if !ok {
+			return
+		}
+
+		if !strings.HasPrefix(href, "http") {
+			href = fmt.Sprintf(m.ViewMangaURL, href)
+		}
+
+		// Chapters are labeled like "279: Boku no Hero Academia 279:"
+		title := strings.TrimSpace(s.Find("b").Text())
+		number := strings.TrimSpace(strings.SplitN(title, ":", 2)[0])
+		if _, err := strconv.ParseFloat(number, 64); err != nil {
+			number = ""
+		}
+
+		lang := "en"
+		if strings.Contains(href, "it-manga") {
+			lang = "it"
+		}
+
+		chapters = append(chapters, models.MangaChapter{
+			Title:    title,
+			Number:   number,
+			URL:      href,
+			Language: lang,
+		})
+	})
 
-	return fmt.Sprintf(m.ViewMangaURL, lastChapter), nil
+	return chapters, nil
 }
 
 // Subscribe method receives a subscription model, this contains information
@@ -153,7 +225,7 @@ func (m *Mangaeden) Subscribe(subscription *models.Subscription) error {
 	}
 
 	subscription.ID = primitive.NewObjectID()
-	subscription.MangaFeed = 3
+	subscription.MangaFeed = models.FeedMangaEden
 
 	subscription.LastChapterURL, _ = m.GetLastMangaChapter(subscription.MangaURL)
 
@@ -166,3 +238,13 @@ func (m *Mangaeden) Subscribe(subscription *models.Subscription) error {
 	return nil
 
 }
+
+func (m *Mangaeden) hasLanguage(lang string) bool {
+	for _, l := range m.Languages {
+		if l == lang {
+			return true
+		}
+	}
+
+	return false
+}
@@ -115,6 +115,18 @@ func TestQueryManga(t *testing.T) {
 		is.True(suggestions != nil)
 		is.Equal(len(suggestions.Suggestions), 4)
 		is.Equal(suggestions.Suggestions[0].Value, "Boku no Hero Academia")
+		is.Equal(suggestions.Suggestions[0].Language, "en")
+	})
+
+	t.Run("Italian titles", func(t *testing.T) {
+		manga.SetLanguages([]string{"it"})
+		defer manga.SetLanguages([]string{"en"})
+
+		suggestions := manga.QueryManga("boku no hero")
+		is.True(suggestions != nil)
+		is.Equal(len(suggestions.Suggestions), 1)
+		is.Equal(suggestions.Suggestions[0].Data, "/it-manga/boku-no-hero-academia/")
+		is.Equal(suggestions.Suggestions[0].Language, "it")
 	})
 }
 
@@ -140,6 +152,25 @@ func TestGetLastMangaChapter(t *testing.T) {
 	})
 }
 
+func TestGetChapters(t *testing.T) {
+	is := is.New(t)
+
+	manga := NewMangaeden(nil)
+	server := testMangaedenReadServer()
+	defer server.Close()
+
+	chapters, err := manga.GetChapters(server.URL)
+	is.NoErr(err)
+	is.True(len(chapters) > 1)
+	is.Equal(chapters[0], models.MangaChapter{
+		Title:    "279: Boku no Hero Academia 279:",
+		Number:   "279",
+		URL:      "https://mangaeden.com/en/en-manga/boku-no-hero-academia/279/1/",
+		Language: "en",
+	})
+	is.Equal(chapters[1].URL, "https://www.mangaeden.com/en/en-manga/boku-no-hero-academia/278/1/")
+}
+
 func TestSubscribe(t *testing.T) {
 	is := is.New(t)
 	opts := &mtest.Options{}
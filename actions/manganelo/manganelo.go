@@ -3,6 +3,7 @@ package manganelo
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
@@ -83,6 +84,7 @@ func (m *Manganelo) QueryManga(name string) *models.ApiQuerySuggestions {
 
 		s.Data = manga.IDEncode
 		s.Value = strings.Title(strip.StripTags(manga.Name))
+		s.Language = "en"
 
 		suggestions.Suggestions = append(suggestions.Suggestions, s)
 	}
@@ -108,7 +110,7 @@ func (m *Manganelo) Subscribe(subscription *models.Subscription) error {
 	}
 
 	subscription.ID = primitive.NewObjectID()
-	subscription.MangaFeed = 2
+	subscription.MangaFeed = models.FeedManganelo
 
 	subscription.LastChapterURL, _ = m.GetLastMangaChapter(subscription.MangaURL)
 
@@ -155,3 +157,90 @@ func (m *Manganelo) GetLastMangaChapter(titleURL string) (string, error) {
 
 	return lastChaperUrl, nil
 }
+
+// GetMangaInfo method receives the URL to a manga title and returns its
+// title, cover, author, status, genres, description and chapters,
+// taken from the title's page. An error might be returned if no URL is
+// supplied or if the page cannot be loaded
+func (m *Manganelo) GetMangaInfo(titleURL string) (*models.MangaInfo, error) {
+
+	if titleURL == "" {
+		log.Println("No title supplied")
+		return nil, errors.New("no title supplied")
+	}
+
+	res, err := http.Get(titleURL)
+	if err != nil {
+		log.Println("Error calling HTTP URL: ", err)
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		log.Println("Status code was not OK: ", res.StatusCode)
+		return nil, fmt.Errorf("status code was not OK: %d", res.StatusCode)
+	}
+
+	page, err := goquery.NewDocumentFromReader(res.Body)
+	if err != nil {
+		log.Println("There was an error getting the page: ", err)
+		return nil, err
+	}
+
+	info := &models.MangaInfo{
+		URL:   titleURL,
+		Feed:  models.FeedManganelo,
+		Title: strings.TrimSpace(page.Find(".story-info-right h1").First().Text()),
+	}
+
+	info.Cover, _ = page.Find(`meta[property="og:image"]`).Attr("content")
+	if info.Cover == "" {
+		info.Cover, _ = page.Find(".info-image img").Attr("src")
+	}
+
+	page.Find("table.variations-tableInfo tr").Each(func(idx int, s *goquery.Selection) {
+		label := strings.ToLower(s.Find("td.table-label").Text())
+		value := s.Find("td.table-value")
+
+		switch {
+		case strings.Contains(label, "alternative"):
+			for _, t := range strings.Split(value.Text(), ";") {
+				if t = strings.TrimSpace(t); t != "" {
+					info.AltTitles = append(info.AltTitles, t)
+				}
+			}
+		case strings.Contains(label, "author"):
+			authors := value.Find("a").Map(func(idx int, a *goquery.Selection) string {
+				return strings.TrimSpace(a.Text())
+			})
+			info.Author = strings.Join(authors, ", ")
+		case strings.Contains(label, "status"):
+			info.Status = strings.TrimSpace(value.Text())
+		case strings.Contains(label, "genres"):
+			info.Genres = value.Find("a").Map(func(idx int, a *goquery.Selection) string {
+				return strings.TrimSpace(a.Text())
+			})
+		}
+	})
+
+	// The description starts with a "Description :" heading
+	description := page.Find("#panel-story-info-description").First()
+	description.Find("h3").First().Remove()
+	info.Description = strings.Join(strings.Fields(description.Text()), " ")
+
+	page.Find("a.chapter-name").Each(func(idx int, s *goquery.Selection) {
+		href, ok := s.Attr("href")
+		if !ok {
+			return
+		}
+
+		info.Chapters = append(info.Chapters, models.MangaChapter{
+			Title:    strings.TrimSpace(s.Text()),
+			URL:      href,
+			Language: "en",
+		})
+	})
+
+	return info, nil
+}
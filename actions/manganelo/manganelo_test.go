@@ -176,6 +176,36 @@ func TestGetLastMangaChapter(t *testing.T) {
 	})
 }
 
+func TestGetMangaInfo(t *testing.T) {
+	is := is.New(t)
+
+	manga := NewManganelo(nil)
+	server := testManganeloReadServer()
+	defer server.Close()
+
+	t.Run("No manga URL supplied", func(t *testing.T) {
+		info, err := manga.GetMangaInfo("")
+		is.True(info == nil)
+		is.True(err != nil)
+	})
+
+	t.Run("Happy path", func(t *testing.T) {
+		info, err := manga.GetMangaInfo(server.URL)
+		is.NoErr(err)
+		is.Equal(info.Feed, 2)
+		is.Equal(info.Title, "Tokyo Ghoul")
+		is.Equal(info.Author, "Ishida Sui")
+		is.Equal(info.Status, "Completed")
+		is.Equal(info.Cover, "https://avt.mkklcdnv6temp.com/35/x/3-1583469087.jpg")
+		is.Equal(info.AltTitles[0], "東京喰種")
+		is.Equal(info.Genres[0], "Action")
+		is.True(strings.HasPrefix(info.Description, "Part 2 : Tokyo Ghoul : Re"))
+		is.Equal(info.Chapters[0].URL, "https://readmanganato.com/manga-od955386/chapter-145")
+		is.Equal(info.Chapters[0].Title, "Chapter 145")
+		is.Equal(info.Chapters[1].Title, "Chapter 144")
+	})
+}
+
 func TestSubscribe(t *testing.T) {
 	is := is.New(t)
 	opts := &mtest.Options{}
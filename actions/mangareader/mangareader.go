@@ -77,6 +77,7 @@ func (m *MangaReader) QueryManga(name string) *models.ApiQuerySuggestions {
 
 		s.Data = manga.NameUnsigned
 		s.Value = manga.Name
+		s.Language = "en"
 
 		suggestions.Suggestions = append(suggestions.Suggestions, s)
 	}
@@ -108,7 +109,7 @@ func (m *MangaReader) Subscribe(subscription *models.Subscription) error {
 	}
 
 	subscription.ID = primitive.NewObjectID()
-	subscription.MangaFeed = 1
+	subscription.MangaFeed = models.FeedMangaReader
 	subscription.LastChapterURL, _ = m.GetLastMangaChapter(subscription.MangaURL)
 
 	_, err := m.DB.MongoClient.Collection("subscription").InsertOne(m.DB.Ctx, subscription)
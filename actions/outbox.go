@@ -0,0 +1,190 @@
+package actions
+
+import (
+	"errors"
+	"log"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/tavomoya/mangagram/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Delivery status of the alerts in the outbox.
+const (
+	AlertPending   = "pending"
+	AlertDelivered = "delivered"
+	AlertFailed    = "failed"
+)
+
+// MaxAlertAttempts is the number of times delivering
+// an alert is tried before it's marked as failed.
+const MaxAlertAttempts = 8
+
+// maxRetryDelay is the longest time between
+// two delivery attempts of the same alert.
+const maxRetryDelay = time.Hour
+
+// retryAfterRegex matches the description of the errors Telegram
+// returns when the bot hits a flood limit, e.g. "api error:
+// Too Many Requests: retry after 35".
+var retryAfterRegex = regexp.MustCompile(`(?i)too many requests: retry after (\d+)`)
+
+// QueueAlert method saves an alert in the 'outbox' collection
+// so it can be delivered at its DeliverAt time, or as soon
+// as possible if it doesn't have one.
+func QueueAlert(db *models.DatabaseConfig, alert *models.QueuedAlert) error {
+
+	if db == nil {
+		log.Println("The DB model is nil")
+		return errors.New("the DB model passed is nil, can't operate")
+	}
+
+	if alert.ChatID == 0 {
+		log.Println("No Chat supplied for alert")
+		return errors.New("no Chat supplied for alert")
+	}
+
+	alert.ID = primitive.NewObjectID()
+	alert.Status = AlertPending
+
+	if alert.DeliverAt.IsZero() {
+		alert.DeliverAt = time.Now()
+	}
+
+	_, err := db.MongoClient.Collection("outbox").InsertOne(db.Ctx, alert)
+	if err != nil {
+		log.Println("There was an error queueing the alert: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetDueAlerts method returns the pending alerts
+// that must be delivered by t, oldest first.
+func GetDueAlerts(db *models.DatabaseConfig, t time.Time) ([]*models.QueuedAlert, error) {
+
+	if db == nil {
+		log.Println("The DB model is nil")
+		return nil, errors.New("the DB model passed is nil, can't operate")
+	}
+
+	cursor, err := db.MongoClient.Collection("outbox").Find(
+		db.Ctx,
+		bson.M{"status": AlertPending, "deliverat": bson.M{"$lte": t}},
+		options.Find().SetSort(bson.M{"deliverat": 1}),
+	)
+	if err != nil {
+		log.Println("There was an error querying the outbox: ", err)
+		return nil, err
+	}
+
+	alerts := make([]*models.QueuedAlert, 0)
+
+	err = cursor.All(db.Ctx, &alerts)
+	if err != nil {
+		log.Println("There was an error decoding the outbox alerts: ", err)
+		return nil, err
+	}
+
+	return alerts, nil
+}
+
+// MarkAlertDelivered method marks an alert
+// in the outbox as delivered.
+func MarkAlertDelivered(db *models.DatabaseConfig, id primitive.ObjectID) error {
+
+	if db == nil {
+		log.Println("The DB model is nil")
+		return errors.New("the DB model passed is nil, can't operate")
+	}
+
+	_, err := db.MongoClient.Collection("outbox").UpdateOne(
+		db.Ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"status": AlertDelivered, "deliveredat": time.Now()}},
+	)
+	if err != nil {
+		log.Println("There was an error marking the alert as delivered: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// RetryAlert method records a failed delivery attempt of an alert
+// and schedules the next one. Telegram flood limits are retried
+// after the time Telegram asks for, other errors with an exponential
+// backoff. After MaxAlertAttempts the alert is marked as failed.
+func RetryAlert(db *models.DatabaseConfig, alert *models.QueuedAlert, sendErr error, t time.Time) error {
+
+	if db == nil {
+		log.Println("The DB model is nil")
+		return errors.New("the DB model passed is nil, can't operate")
+	}
+
+	alert.Attempts++
+	alert.LastError = sendErr.Error()
+
+	if wait, ok := RetryAfter(sendErr); ok {
+		alert.DeliverAt = t.Add(wait)
+	} else if alert.Attempts >= MaxAlertAttempts {
+		alert.Status = AlertFailed
+	} else {
+		alert.DeliverAt = t.Add(retryDelay(alert.Attempts))
+	}
+
+	_, err := db.MongoClient.Collection("outbox").UpdateOne(
+		db.Ctx,
+		bson.M{"_id": alert.ID},
+		bson.M{"$set": bson.M{
+			"status":    alert.Status,
+			"attempts":  alert.Attempts,
+			"lasterror": alert.LastError,
+			"deliverat": alert.DeliverAt,
+		}},
+	)
+	if err != nil {
+		log.Println("There was an error rescheduling the alert: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// RetryAfter function returns how long Telegram asks to wait
+// before sending more messages, if err is a flood limit error.
+func RetryAfter(err error) (time.Duration, bool) {
+	if err == nil {
+		return 0, false
+	}
+
+	match := retryAfterRegex.FindStringSubmatch(err.Error())
+	if match == nil {
+		return 0, false
+	}
+
+	secs, _ := strconv.Atoi(match[1])
+
+	return time.Duration(secs) * time.Second, true
+}
+
+// retryDelay returns the time to wait before the next delivery
+// attempt, doubling from a minute with every failed attempt.
+func retryDelay(attempts int) time.Duration {
+	delay := time.Minute
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > maxRetryDelay {
+		return maxRetryDelay
+	}
+
+	return delay
+}
@@ -0,0 +1,206 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/tavomoya/mangagram/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestQueueAlert(t *testing.T) {
+	opts := &mtest.Options{}
+	opts.ClientType(mtest.Mock)
+	opts.CollectionName("outbox")
+	opts.DatabaseName("mangagram")
+	opts.ShareClient(true)
+
+	mt := mtest.New(t, opts)
+	defer mt.Close()
+
+	is := is.New(t)
+	config := &models.DatabaseConfig{
+		Ctx:         context.Background(),
+		MongoClient: mt.Client.Database("mangagram"),
+	}
+
+	mt.Run("Nil Database", func(t *mtest.T) {
+		err := QueueAlert(nil, &models.QueuedAlert{ChatID: 1})
+		is.True(err != nil)
+	})
+
+	mt.Run("No chat", func(t *mtest.T) {
+		err := QueueAlert(config, &models.QueuedAlert{})
+		is.True(err != nil)
+	})
+
+	mt.Run("Failed to insert", func(t *mtest.T) {
+		mt.AddMockResponses(mtest.CreateWriteErrorsResponse(mtest.WriteError{
+			Code:    11000,
+			Message: "something went wrong",
+		}))
+
+		err := QueueAlert(config, &models.QueuedAlert{ChatID: 1})
+		is.True(err != nil)
+	})
+
+	mt.Run("Success", func(t *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+
+		alert := &models.QueuedAlert{ChatID: 1, MangaName: "One Piece"}
+		err := QueueAlert(config, alert)
+		is.NoErr(err)
+		is.True(!alert.ID.IsZero())
+		is.Equal(alert.Status, AlertPending)
+		is.True(!alert.DeliverAt.IsZero())
+	})
+}
+
+func TestGetDueAlerts(t *testing.T) {
+	opts := &mtest.Options{}
+	opts.ClientType(mtest.Mock)
+	opts.CollectionName("outbox")
+	opts.DatabaseName("mangagram")
+	opts.ShareClient(true)
+
+	mt := mtest.New(t, opts)
+	defer mt.Close()
+
+	is := is.New(t)
+	config := &models.DatabaseConfig{
+		Ctx:         context.Background(),
+		MongoClient: mt.Client.Database("mangagram"),
+	}
+
+	mt.Run("Nil Database", func(t *mtest.T) {
+		_, err := GetDueAlerts(nil, time.Now())
+		is.True(err != nil)
+	})
+
+	mt.Run("Failed to query", func(t *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCommandErrorResponse(mtest.CommandError{
+			Code: 90,
+		}))
+
+		_, err := GetDueAlerts(config, time.Now())
+		is.True(err != nil)
+	})
+
+	mt.Run("Success", func(t *mtest.T) {
+		id := primitive.NewObjectID()
+		deliverAt := time.Date(2021, 5, 10, 7, 0, 0, 0, time.UTC)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "outbox.deliverat", mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: id},
+			{Key: "chatid", Value: int64(1)},
+			{Key: "manganame", Value: "One Piece"},
+			{Key: "chapterurl", Value: "https://example.com/1000"},
+			{Key: "deliverat", Value: deliverAt},
+			{Key: "status", Value: AlertPending},
+		}))
+
+		alerts, err := GetDueAlerts(config, time.Now())
+		is.NoErr(err)
+		is.Equal(len(alerts), 1)
+		is.Equal(alerts[0].ID, id)
+		is.Equal(alerts[0].ChatID, int64(1))
+		is.Equal(alerts[0].ChapterURL, "https://example.com/1000")
+		is.True(alerts[0].DeliverAt.Equal(deliverAt))
+	})
+}
+
+func TestRetryAlert(t *testing.T) {
+	opts := &mtest.Options{}
+	opts.ClientType(mtest.Mock)
+	opts.CollectionName("outbox")
+	opts.DatabaseName("mangagram")
+	opts.ShareClient(true)
+
+	mt := mtest.New(t, opts)
+	defer mt.Close()
+
+	is := is.New(t)
+	config := &models.DatabaseConfig{
+		Ctx:         context.Background(),
+		MongoClient: mt.Client.Database("mangagram"),
+	}
+
+	now := time.Date(2021, 5, 10, 9, 0, 0, 0, time.UTC)
+
+	mt.Run("Nil Database", func(t *mtest.T) {
+		err := RetryAlert(nil, &models.QueuedAlert{}, errors.New("api error"), now)
+		is.True(err != nil)
+	})
+
+	mt.Run("Backoff", func(t *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse(), mtest.CreateSuccessResponse())
+
+		alert := &models.QueuedAlert{ChatID: 1, Status: AlertPending}
+		err := RetryAlert(config, alert, errors.New("api error: Bad Gateway"), now)
+		is.NoErr(err)
+		is.Equal(alert.Attempts, 1)
+		is.Equal(alert.LastError, "api error: Bad Gateway")
+		is.True(alert.DeliverAt.Equal(now.Add(time.Minute)))
+
+		err = RetryAlert(config, alert, errors.New("api error: Bad Gateway"), now)
+		is.NoErr(err)
+		is.True(alert.DeliverAt.Equal(now.Add(2 * time.Minute)))
+		is.Equal(alert.Status, AlertPending)
+	})
+
+	mt.Run("Flood limit", func(t *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+
+		alert := &models.QueuedAlert{ChatID: 1, Status: AlertPending, Attempts: MaxAlertAttempts}
+		err := RetryAlert(config, alert, errors.New("api error: Too Many Requests: retry after 35"), now)
+		is.NoErr(err)
+		is.Equal(alert.Status, AlertPending)
+		is.True(alert.DeliverAt.Equal(now.Add(35 * time.Second)))
+	})
+
+	mt.Run("Too many attempts", func(t *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+
+		alert := &models.QueuedAlert{ChatID: 1, Status: AlertPending, Attempts: MaxAlertAttempts - 1}
+		err := RetryAlert(config, alert, errors.New("api error: Bad Gateway"), now)
+		is.NoErr(err)
+		is.Equal(alert.Status, AlertFailed)
+	})
+
+	mt.Run("Failed to update", func(t *mtest.T) {
+		mt.AddMockResponses(mtest.CreateWriteErrorsResponse(mtest.WriteError{
+			Code:    11000,
+			Message: "something went wrong",
+		}))
+
+		err := RetryAlert(config, &models.QueuedAlert{ChatID: 1}, errors.New("api error"), now)
+		is.True(err != nil)
+	})
+}
+
+func TestRetryAfter(t *testing.T) {
+	is := is.New(t)
+
+	wait, ok := RetryAfter(errors.New("api error: Too Many Requests: retry after 12"))
+	is.True(ok)
+	is.Equal(wait, 12*time.Second)
+
+	_, ok = RetryAfter(errors.New("api error: Bad Request: chat not found"))
+	is.True(!ok)
+
+	_, ok = RetryAfter(nil)
+	is.True(!ok)
+}
+
+func TestRetryDelay(t *testing.T) {
+	is := is.New(t)
+
+	is.Equal(retryDelay(1), time.Minute)
+	is.Equal(retryDelay(3), 4*time.Minute)
+	is.Equal(retryDelay(7), time.Hour)
+	is.Equal(retryDelay(50), time.Hour)
+}
@@ -0,0 +1,98 @@
+package actions
+
+import (
+	"sync"
+	"time"
+
+	"github.com/tavomoya/mangagram/models"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// ManageModes defines who can remove the subscriptions of a group chat
+// and change its feed: only the group's admins, or every member.
+var ManageModes = []string{"admins", "everyone"}
+
+// AdminCacheTTL is how long the admins of a group
+// chat are kept before asking Telegram again.
+const AdminCacheTTL = 10 * time.Minute
+
+// anonymousAdminID is the ID of the user Telegram sends the
+// messages of group admins who stay anonymous as.
+const anonymousAdminID = 1087968824
+
+// AdminLister returns the admins of a chat,
+// like telebot's Bot.AdminsOf method.
+type AdminLister func(chat *tb.Chat) ([]tb.ChatMember, error)
+
+// cachedAdmins are the IDs of the
+// admins of a chat and when they
+// were asked for.
+type cachedAdmins struct {
+	ids     map[int]bool
+	fetched time.Time
+}
+
+// AdminCache keeps the admins of group chats for a while, so
+// checking permissions doesn't ask Telegram for every button.
+type AdminCache struct {
+	mu    sync.Mutex
+	list  AdminLister
+	ttl   time.Duration
+	chats map[int64]cachedAdmins
+}
+
+// NewAdminCache function returns an AdminCache that
+// gets the admins of a chat with list and keeps them
+// for ttl.
+func NewAdminCache(list AdminLister, ttl time.Duration) *AdminCache {
+	return &AdminCache{
+		list:  list,
+		ttl:   ttl,
+		chats: map[int64]cachedAdmins{},
+	}
+}
+
+// IsAdmin method reports whether a user is an admin, or the
+// creator, of a chat. It returns an error if the chat's admins
+// aren't cached and can't be asked for.
+func (c *AdminCache) IsAdmin(chat *tb.Chat, userID int) (bool, error) {
+	if userID == anonymousAdminID {
+		return true, nil
+	}
+
+	c.mu.Lock()
+	cached, ok := c.chats[chat.ID]
+	c.mu.Unlock()
+
+	if !ok || time.Since(cached.fetched) > c.ttl {
+		members, err := c.list(chat)
+		if err != nil {
+			return false, err
+		}
+
+		cached = cachedAdmins{ids: map[int]bool{}, fetched: time.Now()}
+		for _, m := range members {
+			if m.User != nil && (m.Role == tb.Administrator || m.Role == tb.Creator) {
+				cached.ids[m.User.ID] = true
+			}
+		}
+
+		c.mu.Lock()
+		c.chats[chat.ID] = cached
+		c.mu.Unlock()
+	}
+
+	return cached.ids[userID], nil
+}
+
+// CanManage function reports whether a user can remove the subscriptions
+// of a chat and change its feed. Everyone can in private chats and in the
+// groups managed by everyone, otherwise only the group's admins can.
+func CanManage(settings *models.ChatSettings, admins *AdminCache, chat *tb.Chat, userID int) (bool, error) {
+	if chat.Type == tb.ChatPrivate || chat.Type == tb.ChatChannel || settings.ManagedBy == ManageModes[1] {
+		return true, nil
+	}
+
+	return admins.IsAdmin(chat, userID)
+}
@@ -0,0 +1,66 @@
+package actions
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/matryer/is"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+func TestAdminCache(t *testing.T) {
+	is := is.New(t)
+
+	calls := 0
+	admins := NewAdminCache(func(chat *tb.Chat) ([]tb.ChatMember, error) {
+		calls++
+		return []tb.ChatMember{
+			{User: &tb.User{ID: 1}, Role: tb.Creator},
+			{User: &tb.User{ID: 2}, Role: tb.Administrator},
+			{User: &tb.User{ID: 3}, Role: tb.Member},
+		}, nil
+	}, AdminCacheTTL)
+
+	group := &tb.Chat{ID: -100, Type: tb.ChatSuperGroup}
+
+	for id, admin := range map[int]bool{1: true, 2: true, 3: false, 4: false, anonymousAdminID: true} {
+		ok, err := admins.IsAdmin(group, id)
+		is.NoErr(err)
+		is.Equal(ok, admin)
+	}
+
+	// The admins are only asked for once
+	is.Equal(calls, 1)
+
+	failing := NewAdminCache(func(chat *tb.Chat) ([]tb.ChatMember, error) {
+		return nil, errors.New("chat not found")
+	}, AdminCacheTTL)
+
+	ok, err := failing.IsAdmin(group, 1)
+	is.True(!ok)
+	is.True(err != nil)
+}
+
+func TestCanManage(t *testing.T) {
+	is := is.New(t)
+
+	admins := NewAdminCache(func(chat *tb.Chat) ([]tb.ChatMember, error) {
+		return []tb.ChatMember{{User: &tb.User{ID: 1}, Role: tb.Administrator}}, nil
+	}, AdminCacheTTL)
+
+	settings := DefaultChatSettings(-100)
+	group := &tb.Chat{ID: -100, Type: tb.ChatGroup}
+
+	ok, _ := CanManage(settings, admins, group, 1)
+	is.True(ok)
+
+	ok, _ = CanManage(settings, admins, group, 2)
+	is.True(!ok)
+
+	settings.ManagedBy = "everyone"
+	ok, _ = CanManage(settings, admins, group, 2)
+	is.True(ok)
+
+	ok, _ = CanManage(DefaultChatSettings(2), admins, &tb.Chat{ID: 2, Type: tb.ChatPrivate}, 2)
+	is.True(ok)
+}
@@ -0,0 +1,50 @@
+package actions
+
+import (
+	"log"
+	"time"
+
+	"github.com/tavomoya/mangagram/models"
+)
+
+// chatLocation returns the location of a chat's timezone,
+// or UTC if the timezone is not set or can't be loaded.
+func chatLocation(settings *models.ChatSettings) *time.Location {
+	loc, err := time.LoadLocation(settings.Timezone)
+	if err != nil {
+		log.Println("There was an error loading the chat's timezone: ", settings.Timezone, err)
+		return time.UTC
+	}
+
+	return loc
+}
+
+// InQuietHours function reports whether t falls within
+// a chat's quiet hours, in the chat's timezone. Windows
+// where the start hour is after the end hour span midnight.
+func InQuietHours(settings *models.ChatSettings, t time.Time) bool {
+	if settings.QuietStart == settings.QuietEnd {
+		return false
+	}
+
+	h := t.In(chatLocation(settings)).Hour()
+
+	if settings.QuietStart < settings.QuietEnd {
+		return h >= settings.QuietStart && h < settings.QuietEnd
+	}
+
+	return h >= settings.QuietStart || h < settings.QuietEnd
+}
+
+// QuietHoursEnd function returns the first time after t
+// when a chat's quiet hours end, in the chat's timezone.
+func QuietHoursEnd(settings *models.ChatSettings, t time.Time) time.Time {
+	local := t.In(chatLocation(settings))
+
+	end := time.Date(local.Year(), local.Month(), local.Day(), settings.QuietEnd, 0, 0, 0, local.Location())
+	if !end.After(local) {
+		end = time.Date(local.Year(), local.Month(), local.Day()+1, settings.QuietEnd, 0, 0, 0, local.Location())
+	}
+
+	return end
+}
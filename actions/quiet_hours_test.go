@@ -0,0 +1,58 @@
+package actions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestInQuietHours(t *testing.T) {
+	is := is.New(t)
+
+	settings := DefaultChatSettings(1)
+	at := func(h int) time.Time {
+		return time.Date(2021, 5, 10, h, 30, 0, 0, time.UTC)
+	}
+
+	is.True(!InQuietHours(settings, at(3))) // quiet hours off
+
+	settings.QuietStart, settings.QuietEnd = 1, 8
+	is.True(InQuietHours(settings, at(1)))
+	is.True(InQuietHours(settings, at(7)))
+	is.True(!InQuietHours(settings, at(8)))
+	is.True(!InQuietHours(settings, at(0)))
+
+	settings.QuietStart, settings.QuietEnd = 22, 7
+	is.True(InQuietHours(settings, at(23)))
+	is.True(InQuietHours(settings, at(2)))
+	is.True(!InQuietHours(settings, at(7)))
+	is.True(!InQuietHours(settings, at(21)))
+
+	// 02:30 UTC is 22:30 in Santo Domingo (UTC-4)
+	settings.QuietStart, settings.QuietEnd = 1, 8
+	settings.Timezone = "America/Santo_Domingo"
+	is.True(!InQuietHours(settings, at(2)))
+	is.True(InQuietHours(settings, at(6)))
+
+	// Unknown timezones fall back to UTC
+	settings.Timezone = "Nowhere/Nothing"
+	is.True(InQuietHours(settings, at(2)))
+}
+
+func TestQuietHoursEnd(t *testing.T) {
+	is := is.New(t)
+
+	settings := DefaultChatSettings(1)
+	settings.QuietStart, settings.QuietEnd = 22, 7
+
+	end := QuietHoursEnd(settings, time.Date(2021, 5, 10, 23, 15, 0, 0, time.UTC))
+	is.True(end.Equal(time.Date(2021, 5, 11, 7, 0, 0, 0, time.UTC)))
+
+	end = QuietHoursEnd(settings, time.Date(2021, 5, 10, 3, 15, 0, 0, time.UTC))
+	is.True(end.Equal(time.Date(2021, 5, 10, 7, 0, 0, 0, time.UTC)))
+
+	settings.Timezone = "America/Santo_Domingo"
+	end = QuietHoursEnd(settings, time.Date(2021, 5, 11, 3, 15, 0, 0, time.UTC))
+	is.True(end.Equal(time.Date(2021, 5, 11, 11, 0, 0, 0, time.UTC)))
+}
@@ -0,0 +1,210 @@
+package actions
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/tavomoya/mangagram/actions/i18n"
+	"github.com/tavomoya/mangagram/actions/rss"
+	"github.com/tavomoya/mangagram/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// UnreadTitle is a title a user hasn't caught up
+// with, and how many of its chapters they haven't
+// read. Count is a lower bound when Exact is false.
+type UnreadTitle struct {
+	Subscription *models.Subscription
+	Count        int
+	Exact        bool
+}
+
+// MarkChapterRead method saves a chapter as the last one a user has read
+// of a subscription, in the 'read_state' collection.
+func MarkChapterRead(db *models.DatabaseConfig, userID int, chatID int64, subscriptionID primitive.ObjectID, chapter *models.MangaChapter) error {
+
+	if db == nil {
+		log.Println("The DB model is nil")
+		return errors.New("the DB model passed is nil, can't operate")
+	}
+
+	if chapter == nil || chapter.URL == "" {
+		log.Println("No chapter supplied")
+		return errors.New("no chapter supplied")
+	}
+
+	number := chapter.Number
+	if number == "" {
+		number = ChapterNumber(chapter.URL)
+	}
+
+	_, err := db.MongoClient.Collection("read_state").UpdateOne(
+		db.Ctx,
+		bson.M{"userid": userID, "subscriptionid": subscriptionID},
+		bson.M{"$set": bson.M{
+			"chatid":        chatID,
+			"chapterurl":    chapter.URL,
+			"chapternumber": number,
+			"readat":        time.Now(),
+		}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		log.Println("There was an error saving the read state: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetUserReadStates method returns the read state of a user
+// for the subscriptions of a Chat, by subscription ID.
+func GetUserReadStates(db *models.DatabaseConfig, userID int, chatID int64) (map[primitive.ObjectID]*models.ReadState, error) {
+
+	if db == nil {
+		log.Println("The DB model is nil")
+		return nil, errors.New("the DB model passed is nil, can't operate")
+	}
+
+	cursor, err := db.MongoClient.Collection("read_state").Find(db.Ctx, bson.M{"userid": userID, "chatid": chatID})
+	if err != nil {
+		log.Println("There was an error looking for the read states: ", err)
+		return nil, err
+	}
+
+	states := make([]*models.ReadState, 0)
+	err = cursor.All(db.Ctx, &states)
+	if err != nil {
+		log.Println("There was an error decoding the read states: ", err)
+		return nil, err
+	}
+
+	bySub := make(map[primitive.ObjectID]*models.ReadState, len(states))
+	for _, s := range states {
+		bySub[s.SubscriptionID] = s
+	}
+
+	return bySub, nil
+}
+
+// UnreadChapters function returns how many of a title's chapters, newest
+// first, come after the last one read. The chapter read is looked up by
+// URL and then by number; if it isn't in the list, or nothing was read,
+// the whole list is unread and the count is not exact, since the list
+// only has the latest chapters.
+func UnreadChapters(chapters []models.MangaChapter, read *models.ReadState) (int, bool) {
+	if read == nil {
+		return len(chapters), false
+	}
+
+	for i, c := range chapters {
+		if c.URL == read.ChapterURL {
+			return i, true
+		}
+	}
+
+	last, err := strconv.ParseFloat(read.ChapterNumber, 64)
+	if err != nil {
+		return len(chapters), false
+	}
+
+	count := 0
+	for _, c := range chapters {
+		number := c.Number
+		if number == "" {
+			number = ChapterNumber(c.URL)
+		}
+
+		n, err := strconv.ParseFloat(number, 64)
+		if err != nil {
+			continue
+		}
+
+		if n <= last {
+			return count, true
+		}
+
+		count++
+	}
+
+	return count, false
+}
+
+// GetUnreadTitles method returns the titles a Chat is subscribed to that
+// a user has unread chapters of, the ones with the most unread first.
+// Chapters come from the titles' cached info, which GetMangaUpdates keeps
+// up to date, so no feed is queried; titles without cached info and RSS
+// subscriptions, which don't have a chapter list, are left out.
+func GetUnreadTitles(db *models.DatabaseConfig, userID int, chatID int64) ([]UnreadTitle, error) {
+
+	subs, err := GetChatSubscriptions(db, chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	states, err := GetUserReadStates(db, userID, chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	unread := make([]UnreadTitle, 0)
+	for _, sub := range subs {
+		if _, ok := NewMangaInterface(sub.MangaFeed, db).(*rss.RSSFeed); ok {
+			continue
+		}
+
+		info := CachedMangaInfo(db, sub.MangaURL)
+		if info == nil {
+			continue
+		}
+
+		count, exact := UnreadChapters(info.Chapters, states[sub.ID])
+		if count > 0 {
+			unread = append(unread, UnreadTitle{Subscription: sub, Count: count, Exact: exact})
+		}
+	}
+
+	sort.SliceStable(unread, func(i, j int) bool {
+		return unread[i].Count > unread[j].Count
+	})
+
+	return unread, nil
+}
+
+// UnreadMessage function formats the titles a user has unread chapters
+// of as an HTML message in the given locale. Titles that don't fit in a
+// message are left out.
+func UnreadMessage(locale string, titles []UnreadTitle) string {
+	lines := []string{i18n.T(locale, "unread.title")}
+	length := utf8.RuneCountInString(lines[0])
+
+	for _, t := range titles {
+		count := strconv.Itoa(t.Count)
+		if !t.Exact {
+			count += "+"
+		}
+
+		name := fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(t.Subscription.MangaURL), html.EscapeString(t.Subscription.MangaName))
+		line := "• " + i18n.T(locale, "unread.count", name, count)
+
+		length += utf8.RuneCountInString(line) + 1
+		if length > maxMessageLength-2 {
+			lines = append(lines, "…")
+			break
+		}
+
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,175 @@
+package actions
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/tavomoya/mangagram/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestMarkChapterRead(t *testing.T) {
+	opts := &mtest.Options{}
+	opts.ClientType(mtest.Mock)
+	opts.CollectionName("read_state")
+	opts.DatabaseName("mangagram")
+	opts.ShareClient(true)
+
+	mt := mtest.New(t, opts)
+	defer mt.Close()
+
+	is := is.New(t)
+	config := &models.DatabaseConfig{
+		Ctx:         context.Background(),
+		MongoClient: mt.Client.Database("mangagram"),
+	}
+
+	chapter := &models.MangaChapter{URL: "https://example.com/one-piece/1000"}
+
+	mt.Run("Nil Database", func(t *mtest.T) {
+		err := MarkChapterRead(nil, 1, 1, primitive.NewObjectID(), chapter)
+		is.True(err != nil)
+	})
+
+	mt.Run("No chapter supplied", func(t *mtest.T) {
+		err := MarkChapterRead(config, 1, 1, primitive.NewObjectID(), &models.MangaChapter{})
+		is.True(err != nil)
+	})
+
+	mt.Run("Success", func(t *mtest.T) {
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}})
+
+		err := MarkChapterRead(config, 1, 1, primitive.NewObjectID(), chapter)
+		is.NoErr(err)
+	})
+}
+
+func TestUnreadChapters(t *testing.T) {
+	is := is.New(t)
+
+	chapters := []models.MangaChapter{
+		{Number: "1003", URL: "https://example.com/one-piece/1003"},
+		{Number: "1002", URL: "https://example.com/one-piece/1002"},
+		{URL: "https://example.com/one-piece/1001"},
+		{Number: "1000", URL: "https://example.com/one-piece/1000"},
+	}
+
+	count, exact := UnreadChapters(chapters, nil)
+	is.Equal(count, 4)
+	is.True(!exact)
+
+	count, exact = UnreadChapters(chapters, &models.ReadState{ChapterURL: "https://example.com/one-piece/1002"})
+	is.Equal(count, 1)
+	is.True(exact)
+
+	// Chapters are matched by number when the URL changed
+	count, exact = UnreadChapters(chapters, &models.ReadState{ChapterURL: "https://other.com/1001", ChapterNumber: "1001"})
+	is.Equal(count, 2)
+	is.True(exact)
+
+	// The list doesn't go as far back as the chapter read
+	count, exact = UnreadChapters(chapters, &models.ReadState{ChapterNumber: "990"})
+	is.Equal(count, 4)
+	is.True(!exact)
+}
+
+func TestGetUnreadTitles(t *testing.T) {
+	opts := &mtest.Options{}
+	opts.ClientType(mtest.Mock)
+	opts.CollectionName("subscription")
+	opts.DatabaseName("mangagram")
+	opts.ShareClient(true)
+
+	mt := mtest.New(t, opts)
+	defer mt.Close()
+
+	is := is.New(t)
+	config := &models.DatabaseConfig{
+		Ctx:         context.Background(),
+		MongoClient: mt.Client.Database("mangagram"),
+	}
+
+	mt.Run("Nil Database", func(t *mtest.T) {
+		titles, err := GetUnreadTitles(nil, 1, 1)
+		is.True(titles == nil)
+		is.True(err != nil)
+	})
+
+	mt.Run("Unread chapters", func(t *mtest.T) {
+		caughtUp, behind := primitive.NewObjectID(), primitive.NewObjectID()
+		chapters := bson.A{
+			bson.D{{Key: "number", Value: "1001"}, {Key: "url", Value: "https://manganelo.com/chapter/one_piece/chapter_1001"}},
+			bson.D{{Key: "number", Value: "1000"}, {Key: "url", Value: "https://manganelo.com/chapter/one_piece/chapter_1000"}},
+		}
+
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "subscription.subscription", mtest.FirstBatch,
+				bson.D{
+					{Key: "_id", Value: caughtUp},
+					{Key: "manganame", Value: "Naruto"},
+					{Key: "mangaurl", Value: "https://manganelo.com/manga/naruto"},
+					{Key: "mangafeed", Value: 2},
+				},
+				bson.D{
+					{Key: "_id", Value: behind},
+					{Key: "manganame", Value: "One Piece"},
+					{Key: "mangaurl", Value: "https://manganelo.com/manga/one_piece"},
+					{Key: "mangafeed", Value: 2},
+				},
+				bson.D{
+					{Key: "_id", Value: primitive.NewObjectID()},
+					{Key: "manganame", Value: "Blog"},
+					{Key: "mangaurl", Value: "https://example.com/feed.xml"},
+					{Key: "mangafeed", Value: 6},
+				},
+			),
+			mtest.CreateCursorResponse(0, "read_state.read_state", mtest.FirstBatch,
+				bson.D{
+					{Key: "subscriptionid", Value: caughtUp},
+					{Key: "chapterurl", Value: "https://manganelo.com/chapter/naruto/chapter_700"},
+				},
+				bson.D{
+					{Key: "subscriptionid", Value: behind},
+					{Key: "chapterurl", Value: "https://manganelo.com/chapter/one_piece/chapter_1000"},
+				},
+			),
+			mtest.CreateCursorResponse(0, "manga_info.manga_info", mtest.FirstBatch, bson.D{
+				{Key: "url", Value: "https://manganelo.com/manga/naruto"},
+				{Key: "chapters", Value: bson.A{
+					bson.D{{Key: "number", Value: "700"}, {Key: "url", Value: "https://manganelo.com/chapter/naruto/chapter_700"}},
+				}},
+				{Key: "updatedat", Value: time.Now()},
+			}),
+			mtest.CreateCursorResponse(0, "manga_info.manga_info", mtest.FirstBatch, bson.D{
+				{Key: "url", Value: "https://manganelo.com/manga/one_piece"},
+				{Key: "chapters", Value: chapters},
+				{Key: "updatedat", Value: time.Now()},
+			}),
+		)
+
+		titles, err := GetUnreadTitles(config, 1, 1)
+		is.NoErr(err)
+		is.Equal(len(titles), 1)
+		is.Equal(titles[0].Subscription.ID, behind)
+		is.Equal(titles[0].Count, 1)
+		is.True(titles[0].Exact)
+	})
+}
+
+func TestUnreadMessage(t *testing.T) {
+	is := is.New(t)
+
+	titles := []UnreadTitle{
+		{Subscription: &models.Subscription{MangaName: "One Piece", MangaURL: "https://example.com/op"}, Count: 100},
+		{Subscription: &models.Subscription{MangaName: "Naruto & Boruto", MangaURL: "https://example.com/n"}, Count: 2, Exact: true},
+	}
+
+	msg := UnreadMessage("en", titles)
+	is.True(strings.Contains(msg, `• <a href="https://example.com/op">One Piece</a>: 100+ unread`))
+	is.True(strings.Contains(msg, `• <a href="https://example.com/n">Naruto &amp; Boruto</a>: 2 unread`))
+}
@@ -0,0 +1,337 @@
+package rss
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/tavomoya/mangagram/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxFeedSize is the size, in bytes,
+// of the largest feed document read.
+const maxFeedSize = 5 << 20
+
+// errPrivateAddress is returned when a feed URL points
+// to a private, loopback or otherwise internal address.
+var errPrivateAddress = errors.New("the feed is not on a public address")
+
+// privateNetworks are the networks feeds can't be fetched from, so
+// users can't make the bot reach the services running next to it.
+var privateNetworks = func() []*net.IPNet {
+	cidrs := []string{
+		"0.0.0.0/8", "10.0.0.0/8", "100.64.0.0/10", "127.0.0.0/8", "169.254.0.0/16",
+		"172.16.0.0/12", "192.168.0.0/16", "::/128", "::1/128", "fc00::/7", "fe80::/10",
+	}
+
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, _ := net.ParseCIDR(cidr)
+		networks = append(networks, network)
+	}
+
+	return networks
+}()
+
+// publicOnly refuses connections to the private networks. It's
+// checked for every address dialed, so redirects and host names
+// resolving to them are refused too.
+func publicOnly(network, address string, c syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsMulticast() || ip.IsUnspecified() {
+		return errPrivateAddress
+	}
+
+	for _, n := range privateNetworks {
+		if n.Contains(ip) {
+			return errPrivateAddress
+		}
+	}
+
+	return nil
+}
+
+// newClient returns the HTTP client feeds are fetched with,
+// which gives up on slow feeds and refuses private addresses.
+func newClient() *http.Client {
+	dialer := &net.Dialer{Timeout: 10 * time.Second, Control: publicOnly}
+
+	return &http.Client{
+		Timeout: 30 * time.Second,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+				return dialer.DialContext(ctx, network, address)
+			},
+			TLSHandshakeTimeout:   10 * time.Second,
+			ResponseHeaderTimeout: 10 * time.Second,
+		},
+	}
+}
+
+// RSSFeed is a struct used to attach all
+// functionality available for generic
+// RSS and Atom feeds. Every item in the feed
+// is treated as a chapter.
+type RSSFeed struct {
+	DB           *models.DatabaseConfig
+	ViewMangaURL string
+
+	// Client fetches the feeds, it refuses private addresses
+	Client *http.Client
+}
+
+// item is a feed entry normalized
+// from either RSS or Atom.
+type item struct {
+	Title     string
+	URL       string
+	Published time.Time
+}
+
+// NewRSSFeed function returns a pointer to a RSSFeed
+// struct that can be used to call all of its methods
+func NewRSSFeed(db *models.DatabaseConfig) *RSSFeed {
+	return &RSSFeed{
+		DB:           db,
+		ViewMangaURL: "%s",
+		Client:       newClient(),
+	}
+}
+
+// ViewManga method returns a string with the Manga's URL.
+// For RSS feeds the suggestion data is already the full URL.
+func (r *RSSFeed) ViewManga() string {
+	return r.ViewMangaURL
+}
+
+// QueryManga method receives the URL of a RSS/Atom feed, it fetches the
+// feed and returns a single suggestion with the feed's title. It returns
+// nil if the URL is not valid or does not point to a RSS/Atom document.
+func (r *RSSFeed) QueryManga(feedURL string) *models.ApiQuerySuggestions {
+
+	if feedURL == "" {
+		return nil
+	}
+
+	u, err := url.Parse(feedURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		log.Println("Invalid feed URL: ", feedURL)
+		return nil
+	}
+
+	doc, err := r.fetch(feedURL)
+	if err != nil {
+		log.Println("There was an error fetching the feed: ", err)
+		return nil
+	}
+
+	title := strings.TrimSpace(doc.Channel.Title)
+	if title == "" {
+		title = strings.TrimSpace(doc.Title)
+	}
+
+	if title == "" {
+		title = u.Host
+	}
+
+	suggestions := new(models.ApiQuerySuggestions)
+	suggestions.Suggestions = append(suggestions.Suggestions, models.MangaSuggestions{
+		Data:  feedURL,
+		Value: title,
+	})
+
+	return suggestions
+}
+
+// GetLastMangaChapter method receives the URL to a RSS/Atom feed and returns
+// the URL of the most recent item. An error might be returned if the feed
+// cannot be fetched or parsed.
+func (r *RSSFeed) GetLastMangaChapter(feedURL string) (string, error) {
+
+	chapter, err := r.GetLastChapter(feedURL)
+	if err != nil || chapter == nil {
+		return "", err
+	}
+
+	return chapter.URL, nil
+}
+
+// GetLastChapter method receives the URL to a RSS/Atom feed and returns
+// its most recent item as a chapter, or nil if the feed has no items. An
+// error might be returned if the feed cannot be fetched or parsed.
+func (r *RSSFeed) GetLastChapter(feedURL string) (*models.MangaChapter, error) {
+
+	if feedURL == "" {
+		log.Println("No feed supplied")
+		return nil, nil
+	}
+
+	doc, err := r.fetch(feedURL)
+	if err != nil {
+		log.Println("There was an error fetching the feed: ", err)
+		return nil, err
+	}
+
+	items := parseItems(doc)
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	// Most feeds are sorted newest first, but not all of them,
+	// so the publish date wins whenever it can be parsed.
+	last := items[0]
+	for _, i := range items[1:] {
+		if i.Published.After(last.Published) {
+			last = i
+		}
+	}
+
+	return &models.MangaChapter{
+		Title: last.Title,
+		URL:   last.URL,
+	}, nil
+}
+
+// Subscribe method receives a subscription model, this contains information
+// about a User or Group that wants to receive alerts from a certain RSS feed.
+// The method will save this in a 'Subscription' collection in MongoDB, as well as
+// set a value for the lastChapter of the feed.
+func (r *RSSFeed) Subscribe(subscription *models.Subscription) error {
+
+	// Validate subscription data
+	if subscription.MangaName == "" || subscription.MangaURL == "" {
+		log.Println("No feed supplied for subscription")
+		return errors.New("no feed supplied for subscription")
+	}
+
+	if subscription.ChatID == 0 {
+		log.Println("No Chat supplied for subscription")
+		return errors.New("no Chat supplied for subscription")
+	}
+
+	subscription.ID = primitive.NewObjectID()
+	subscription.MangaFeed = models.FeedRSS
+
+	subscription.LastChapterURL, _ = r.GetLastMangaChapter(subscription.MangaURL)
+
+	_, err := r.DB.MongoClient.Collection("subscription").InsertOne(r.DB.Ctx, subscription)
+	if err != nil && !strings.Contains(err.Error(), "subscription_unq") {
+		log.Println("There was an error creating new subscription: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// fetch downloads and parses a RSS or Atom document. Documents
+// bigger than maxFeedSize are not read.
+func (r *RSSFeed) fetch(feedURL string) (*models.RSSDocument, error) {
+	res, err := r.Client.Get(feedURL)
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status code was not OK: %d", res.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(res.Body, maxFeedSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(body) > maxFeedSize {
+		return nil, fmt.Errorf("the feed is bigger than %d bytes", maxFeedSize)
+	}
+
+	doc := new(models.RSSDocument)
+	err = xml.Unmarshal(body, doc)
+	if err != nil {
+		return nil, err
+	}
+
+	if doc.XMLName.Local != "rss" && doc.XMLName.Local != "feed" {
+		return nil, fmt.Errorf("unsupported feed document: %s", doc.XMLName.Local)
+	}
+
+	return doc, nil
+}
+
+// parseItems normalizes the items of a RSS
+// or Atom document, skipping items with no URL.
+func parseItems(doc *models.RSSDocument) []item {
+	items := make([]item, 0)
+
+	for _, i := range doc.Channel.Items {
+		link := strings.TrimSpace(i.Link)
+		if link == "" {
+			link = strings.TrimSpace(i.GUID)
+		}
+
+		if link == "" {
+			continue
+		}
+
+		published, _ := time.Parse(time.RFC1123Z, strings.TrimSpace(i.PubDate))
+		if published.IsZero() {
+			published, _ = time.Parse(time.RFC1123, strings.TrimSpace(i.PubDate))
+		}
+
+		items = append(items, item{
+			Title:     strings.TrimSpace(i.Title),
+			URL:       link,
+			Published: published,
+		})
+	}
+
+	for _, e := range doc.Entries {
+		link := ""
+		for _, l := range e.Links {
+			if l.Rel == "" || l.Rel == "alternate" {
+				link = l.Href
+				break
+			}
+		}
+
+		if link == "" {
+			link = strings.TrimSpace(e.ID)
+		}
+
+		if link == "" {
+			continue
+		}
+
+		date := e.Published
+		if date == "" {
+			date = e.Updated
+		}
+		published, _ := time.Parse(time.RFC3339, strings.TrimSpace(date))
+
+		items = append(items, item{
+			Title:     strings.TrimSpace(e.Title),
+			URL:       link,
+			Published: published,
+		})
+	}
+
+	return items
+}
@@ -0,0 +1,194 @@
+package rss
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/tavomoya/mangagram/models"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func testFeedServer() *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rss":
+			file, _ := ioutil.ReadFile("./../../test/rss-feed.xml")
+			rw.Header().Set("Content-Type", "application/rss+xml")
+			rw.WriteHeader(http.StatusOK)
+			rw.Write(file)
+		case "/atom":
+			file, _ := ioutil.ReadFile("./../../test/atom-feed.xml")
+			rw.Header().Set("Content-Type", "application/atom+xml")
+			rw.WriteHeader(http.StatusOK)
+			rw.Write(file)
+		case "/html":
+			rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+			rw.WriteHeader(http.StatusOK)
+			rw.Write([]byte(`<html><head><title>Not a feed</title></head></html>`))
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	return server
+}
+
+func TestViewManga(t *testing.T) {
+	is := is.New(t)
+	feed := NewRSSFeed(nil)
+
+	is.Equal(feed.ViewManga(), "%s")
+}
+
+func TestQueryManga(t *testing.T) {
+	is := is.New(t)
+	feed := NewRSSFeed(nil)
+	server := testFeedServer()
+	defer server.Close()
+
+	// The test server is on a loopback address
+	feed.Client = server.Client()
+
+	t.Run("No feed URL", func(t *testing.T) {
+		suggestions := feed.QueryManga("")
+		is.Equal(suggestions, nil)
+	})
+
+	t.Run("Not a URL", func(t *testing.T) {
+		suggestions := feed.QueryManga("tokyo ghoul")
+		is.Equal(suggestions, nil)
+	})
+
+	t.Run("Non-200 response", func(t *testing.T) {
+		suggestions := feed.QueryManga(server.URL + "/missing")
+		is.Equal(suggestions, nil)
+	})
+
+	t.Run("Not a feed document", func(t *testing.T) {
+		suggestions := feed.QueryManga(server.URL + "/html")
+		is.Equal(suggestions, nil)
+	})
+
+	t.Run("RSS feed", func(t *testing.T) {
+		suggestions := feed.QueryManga(server.URL + "/rss")
+		is.True(suggestions != nil)
+		is.Equal(len(suggestions.Suggestions), 1)
+		is.Equal(suggestions.Suggestions[0].Value, "Tokyo Ghoul Scans")
+		is.Equal(suggestions.Suggestions[0].Data, server.URL+"/rss")
+	})
+
+	t.Run("Atom feed", func(t *testing.T) {
+		suggestions := feed.QueryManga(server.URL + "/atom")
+		is.True(suggestions != nil)
+		is.Equal(suggestions.Suggestions[0].Value, "One Piece Releases")
+	})
+}
+
+func TestPrivateAddresses(t *testing.T) {
+	is := is.New(t)
+	feed := NewRSSFeed(nil)
+	server := testFeedServer()
+	defer server.Close()
+
+	_, err := feed.GetLastMangaChapter(server.URL + "/rss")
+	is.True(errors.Is(err, errPrivateAddress))
+
+	for _, ip := range []string{"10.0.0.1", "172.16.5.4", "192.168.1.1", "169.254.169.254", "::1", "fd00::1"} {
+		is.Equal(publicOnly("tcp", net.JoinHostPort(ip, "80"), nil), errPrivateAddress)
+	}
+
+	is.NoErr(publicOnly("tcp", "93.184.216.34:443", nil))
+}
+
+func TestGetLastMangaChapter(t *testing.T) {
+	is := is.New(t)
+	feed := NewRSSFeed(nil)
+	server := testFeedServer()
+	defer server.Close()
+
+	// The test server is on a loopback address
+	feed.Client = server.Client()
+
+	t.Run("No feed URL supplied", func(t *testing.T) {
+		url, err := feed.GetLastMangaChapter("")
+		is.Equal(url, "")
+		is.NoErr(err)
+	})
+
+	t.Run("Feed not found", func(t *testing.T) {
+		url, err := feed.GetLastMangaChapter(server.URL + "/missing")
+		is.Equal(url, "")
+		is.True(err != nil)
+	})
+
+	t.Run("RSS feed, newest item by date", func(t *testing.T) {
+		url, err := feed.GetLastMangaChapter(server.URL + "/rss")
+		is.NoErr(err)
+		is.Equal(url, "https://scans.example.com/tokyo-ghoul/chapter-145")
+	})
+
+	t.Run("Atom feed", func(t *testing.T) {
+		url, err := feed.GetLastMangaChapter(server.URL + "/atom")
+		is.NoErr(err)
+		is.Equal(url, "https://releases.example.com/one-piece/1019")
+	})
+}
+
+func TestSubscribe(t *testing.T) {
+	is := is.New(t)
+	opts := &mtest.Options{}
+	opts.ClientType(mtest.Mock)
+	opts.CollectionName("subscription")
+	opts.DatabaseName("mangagram")
+	opts.ShareClient(true)
+
+	mt := mtest.New(t, opts)
+	defer mt.Close()
+
+	feed := NewRSSFeed(&models.DatabaseConfig{
+		Ctx:         context.Background(),
+		MongoClient: mt.Client.Database("mangagram"),
+	})
+	server := testFeedServer()
+	defer server.Close()
+
+	// The test server is on a loopback address
+	feed.Client = server.Client()
+
+	t.Run("No feed supplied", func(t *testing.T) {
+		err := feed.Subscribe(&models.Subscription{})
+		is.True(err != nil)
+		is.True(strings.Contains(err.Error(), "no feed supplied"))
+	})
+
+	t.Run("No Chat ID supplied", func(t *testing.T) {
+		err := feed.Subscribe(&models.Subscription{
+			MangaName: "Tokyo Ghoul Scans",
+			MangaURL:  server.URL + "/rss",
+		})
+		is.True(err != nil)
+		is.True(strings.Contains(err.Error(), "no Chat supplied"))
+	})
+
+	t.Run("Happy path", func(t *testing.T) {
+		sub := &models.Subscription{
+			MangaName: "Tokyo Ghoul Scans",
+			MangaURL:  server.URL + "/rss",
+			ChatID:    10,
+		}
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+
+		err := feed.Subscribe(sub)
+		is.NoErr(err)
+		is.Equal(sub.MangaFeed, 6)
+		is.Equal(sub.LastChapterURL, "https://scans.example.com/tokyo-ghoul/chapter-145")
+	})
+}
@@ -0,0 +1,244 @@
+package actions
+
+import (
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/tavomoya/mangagram/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// minFuzzyScore is the similarity a known title needs
+// to a query to be searched for instead of the query.
+const minFuzzyScore = 0.75
+
+// maxTitleSearches is the maximum number of titles searched
+// in a feed for the series that look like a query.
+const maxTitleSearches = 4
+
+// SearchManga function searches a feed for a manga title. The query is
+// sent as typed and then normalized (see NormalizeTitle), and the results
+// are ranked by how similar they are to it. If the feed finds nothing,
+// the titles and alt titles of the known manga series that look like the
+// query are searched instead, so typos and other romanizations of a title
+// (e.g. "Shingeki no Kyojin" for "Attack on Titan") still find it. It
+// returns nil if nothing is found.
+func SearchManga(db *models.DatabaseConfig, feed MangaFeedInterface, query string) *models.ApiQuerySuggestions {
+
+	normalized := NormalizeTitle(query)
+	if normalized == "" {
+		return nil
+	}
+
+	queries := []string{strings.TrimSpace(query)}
+	if normalized != strings.ToLower(queries[0]) {
+		queries = append(queries, normalized)
+	}
+
+	for _, q := range queries {
+		if res := feed.QueryManga(q); res != nil && len(res.Suggestions) > 0 {
+			return RankSuggestions(normalized, res)
+		}
+	}
+
+	searches := 0
+	for _, manga := range knownManga(db, normalized) {
+		for _, title := range append([]string{manga.Title}, manga.AltTitles...) {
+			if searches >= maxTitleSearches {
+				return nil
+			}
+			searches++
+
+			if res := feed.QueryManga(title); res != nil && len(res.Suggestions) > 0 {
+				return RankSuggestions(NormalizeTitle(title), res)
+			}
+		}
+	}
+
+	return nil
+}
+
+// RankSuggestions function sorts the suggestions of a search by how
+// similar their titles are to the normalized query, most similar first.
+// Ties go to the title closest to the whole query, so "Tokyo Ghoul" comes
+// before "Tokyo Ghoul: Jack"; suggestions that are as similar keep the
+// feed's order.
+func RankSuggestions(normalized string, res *models.ApiQuerySuggestions) *models.ApiQuerySuggestions {
+	type score struct{ similarity, whole float64 }
+
+	scores := make(map[string]score, len(res.Suggestions))
+	for _, s := range res.Suggestions {
+		title := NormalizeTitle(s.Value)
+		scores[s.Value] = score{TitleSimilarity(normalized, title), editSimilarity(normalized, title)}
+	}
+
+	ranked := &models.ApiQuerySuggestions{
+		Suggestions: append([]models.MangaSuggestions{}, res.Suggestions...),
+	}
+
+	sort.SliceStable(ranked.Suggestions, func(i, j int) bool {
+		a, b := scores[ranked.Suggestions[i].Value], scores[ranked.Suggestions[j].Value]
+		if a.similarity != b.similarity {
+			return a.similarity > b.similarity
+		}
+
+		return a.whole > b.whole
+	})
+
+	return ranked
+}
+
+// TitleSimilarity function returns how similar two normalized titles are,
+// from 0 to 1. It's the best of the edit distance between the whole titles
+// and the average edit distance between each word of a and its closest
+// word in b, so "tokyo gul" is close to "tokyo ghoul re".
+func TitleSimilarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+
+	if a == "" || b == "" {
+		return 0
+	}
+
+	whole := editSimilarity(a, b)
+
+	words := strings.Fields(a)
+	candidates := strings.Fields(b)
+	total := 0.0
+	for _, w := range words {
+		best := 0.0
+		for _, c := range candidates {
+			if s := editSimilarity(w, c); s > best {
+				best = s
+			}
+		}
+		total += best
+	}
+
+	if byWord := total / float64(len(words)); byWord > whole {
+		return byWord
+	}
+
+	return whole
+}
+
+// editSimilarity returns 1 minus the Levenshtein distance
+// between a and b relative to the longest of them.
+func editSimilarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+
+	if longest == 0 {
+		return 1
+	}
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			curr[j] = min3(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return 1 - float64(prev[len(rb)])/float64(longest)
+}
+
+// min3 returns the smallest of three numbers.
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+
+	if c < a {
+		a = c
+	}
+
+	return a
+}
+
+// knownManga returns the known manga series with a title or alt title
+// similar to the normalized query, most similar first. Candidates are
+// all the series with a title that has a word starting like one of the
+// query's words.
+func knownManga(db *models.DatabaseConfig, normalized string) []*models.Manga {
+	if db == nil {
+		return nil
+	}
+
+	prefixes := []string{}
+	for _, w := range strings.Fields(normalized) {
+		r := []rune(w)
+		if len(r) < 3 {
+			continue
+		}
+
+		prefixes = append(prefixes, regexp.QuoteMeta(string(r[:3])))
+	}
+
+	if len(prefixes) == 0 {
+		prefixes = append(prefixes, regexp.QuoteMeta(normalized))
+	}
+
+	cursor, err := db.MongoClient.Collection("manga").Find(
+		db.Ctx,
+		bson.M{"titles": bson.M{"$regex": "(^| )(" + strings.Join(prefixes, "|") + ")"}},
+		options.Find().SetProjection(bson.M{"title": 1, "alttitles": 1, "titles": 1}),
+	)
+	if err != nil {
+		log.Println("There was an error looking for known manga: ", err)
+		return nil
+	}
+
+	defer cursor.Close(db.Ctx)
+
+	// Every candidate is scored, only the matches are kept
+	scores := map[*models.Manga]float64{}
+	matches := make([]*models.Manga, 0)
+	for cursor.Next(db.Ctx) {
+		manga := new(models.Manga)
+		err = cursor.Decode(manga)
+		if err != nil {
+			log.Println("There was an error decoding known manga: ", err)
+			continue
+		}
+
+		for _, t := range manga.Titles {
+			if s := TitleSimilarity(normalized, t); s > scores[manga] {
+				scores[manga] = s
+			}
+		}
+
+		if scores[manga] >= minFuzzyScore {
+			matches = append(matches, manga)
+		}
+	}
+
+	if err = cursor.Err(); err != nil {
+		log.Println("There was an error looking for known manga: ", err)
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return scores[matches[i]] > scores[matches[j]]
+	})
+
+	return matches
+}
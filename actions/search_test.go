@@ -0,0 +1,142 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/tavomoya/mangagram/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// testQueryFeed is a manga feed that only finds
+// titles for the queries it knows, and keeps the
+// queries it got.
+type testQueryFeed struct {
+	testSearchFeed
+	results map[string][]models.MangaSuggestions
+	queries []string
+}
+
+func (f *testQueryFeed) QueryManga(name string) *models.ApiQuerySuggestions {
+	f.queries = append(f.queries, name)
+	return &models.ApiQuerySuggestions{Suggestions: f.results[name]}
+}
+
+func TestTitleSimilarity(t *testing.T) {
+	is := is.New(t)
+
+	is.Equal(TitleSimilarity("one piece", "one piece"), 1.0)
+	is.Equal(TitleSimilarity("", "one piece"), 0.0)
+	is.True(TitleSimilarity("tokyo gul", "tokyo ghoul re") >= minFuzzyScore)
+	is.True(TitleSimilarity("shingeky no kyojn", "shingeki no kyojin") >= minFuzzyScore)
+	is.True(TitleSimilarity("one piece", "naruto") < minFuzzyScore)
+}
+
+func TestRankSuggestions(t *testing.T) {
+	is := is.New(t)
+
+	res := &models.ApiQuerySuggestions{Suggestions: []models.MangaSuggestions{
+		{Data: "1", Value: "Tokyo Ghoul: Jack"},
+		{Data: "2", Value: "Tokyo Ghoul"},
+		{Data: "3", Value: "Tokyo Revengers"},
+	}}
+
+	ranked := RankSuggestions("tokyo ghoul", res)
+	is.Equal(ranked.Suggestions[0].Data, "2")
+	is.Equal(ranked.Suggestions[1].Data, "1")
+	is.Equal(ranked.Suggestions[2].Data, "3")
+
+	// The feed's results are left as they were
+	is.Equal(res.Suggestions[0].Data, "1")
+}
+
+func TestSearchManga(t *testing.T) {
+	opts := &mtest.Options{}
+	opts.ClientType(mtest.Mock)
+	opts.CollectionName("manga")
+	opts.DatabaseName("mangagram")
+	opts.ShareClient(true)
+
+	mt := mtest.New(t, opts)
+	defer mt.Close()
+
+	is := is.New(t)
+	config := &models.DatabaseConfig{
+		Ctx:         context.Background(),
+		MongoClient: mt.Client.Database("mangagram"),
+	}
+
+	mt.Run("Empty query", func(t *mtest.T) {
+		feed := &testQueryFeed{}
+		is.True(SearchManga(config, feed, " ?! ") == nil)
+		is.Equal(len(feed.queries), 0)
+	})
+
+	mt.Run("Normalized query", func(t *mtest.T) {
+		feed := &testQueryFeed{results: map[string][]models.MangaSuggestions{
+			"kimetsu no yaiba": {{Data: "kny", Value: "Kimetsu no Yaiba"}},
+		}}
+
+		res := SearchManga(config, feed, "Kimetsu no Yaiba!!")
+		is.Equal(res.Suggestions[0].Data, "kny")
+		is.Equal(feed.queries, []string{"Kimetsu no Yaiba!!", "kimetsu no yaiba"})
+	})
+
+	mt.Run("Alt title of a known manga", func(t *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "manga.manga", mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: primitive.NewObjectID()},
+			{Key: "title", Value: "Attack on Titan"},
+			{Key: "alttitles", Value: bson.A{"Shingeki no Kyojin"}},
+			{Key: "titles", Value: bson.A{"attack on titan", "shingeki no kyojin"}},
+		}))
+
+		feed := &testQueryFeed{results: map[string][]models.MangaSuggestions{
+			"Shingeki no Kyojin": {{Data: "snk", Value: "Shingeki no Kyojin"}},
+		}}
+
+		res := SearchManga(config, feed, "shingeky no kyojn")
+		is.Equal(res.Suggestions[0].Data, "snk")
+		is.Equal(feed.queries, []string{"shingeky no kyojn", "Attack on Titan", "Shingeki no Kyojin"})
+	})
+
+	mt.Run("Best match after many candidates", func(t *mtest.T) {
+		docs := []bson.D{}
+		for i := 0; i < 30; i++ {
+			title := fmt.Sprintf("One Outs %d", i)
+			docs = append(docs, bson.D{
+				{Key: "_id", Value: primitive.NewObjectID()},
+				{Key: "title", Value: title},
+				{Key: "titles", Value: bson.A{NormalizeTitle(title)}},
+			})
+		}
+		docs = append(docs, bson.D{
+			{Key: "_id", Value: primitive.NewObjectID()},
+			{Key: "title", Value: "One Piece"},
+			{Key: "titles", Value: bson.A{"one piece"}},
+		})
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "manga.manga", mtest.FirstBatch, docs...))
+
+		feed := &testQueryFeed{results: map[string][]models.MangaSuggestions{
+			"One Piece": {{Data: "op", Value: "One Piece"}},
+		}}
+
+		res := SearchManga(config, feed, "one pice")
+		is.Equal(res.Suggestions[0].Data, "op")
+		is.Equal(feed.queries, []string{"one pice", "One Piece"})
+
+		// The candidates aren't limited before they're scored
+		_, err := mt.GetStartedEvent().Command.LookupErr("limit")
+		is.True(err != nil)
+	})
+
+	mt.Run("Nothing found", func(t *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "manga.manga", mtest.FirstBatch))
+
+		feed := &testQueryFeed{}
+		is.True(SearchManga(config, feed, "one piece") == nil)
+	})
+}
@@ -0,0 +1,254 @@
+package selector
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tavomoya/mangagram/models"
+
+	"github.com/PuerkitoBio/goquery"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gopkg.in/yaml.v2"
+)
+
+// MinFeedCode is the lowest code a selector feed can use,
+// lower codes are reserved for built-in feeds.
+const MinFeedCode = 100
+
+// SelectorFeed is a struct used to attach all functionality
+// available within a manga source described by a
+// SelectorFeedConfig.
+type SelectorFeed struct {
+	DB     *models.DatabaseConfig
+	Config models.SelectorFeedConfig
+}
+
+// NewSelectorFeed function returns a pointer to a SelectorFeed
+// struct that can be used to call all of its methods
+func NewSelectorFeed(config models.SelectorFeedConfig, db *models.DatabaseConfig) *SelectorFeed {
+	if config.HrefAttr == "" {
+		config.HrefAttr = "href"
+	}
+
+	if config.ChapterAttr == "" {
+		config.ChapterAttr = "href"
+	}
+
+	return &SelectorFeed{
+		DB:     db,
+		Config: config,
+	}
+}
+
+// LoadConfig function reads a list of selector feeds from a YAML or
+// JSON file (chosen by the file extension) and validates them. It
+// returns an error if the file can't be read or any feed is invalid.
+func LoadConfig(path string) ([]models.SelectorFeedConfig, error) {
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		log.Println("There was an error reading selector feeds file: ", err)
+		return nil, err
+	}
+
+	feeds := make([]models.SelectorFeedConfig, 0)
+
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		err = json.Unmarshal(data, &feeds)
+	} else {
+		err = yaml.Unmarshal(data, &feeds)
+	}
+	if err != nil {
+		log.Println("There was an error parsing selector feeds file: ", err)
+		return nil, err
+	}
+
+	codes := make(map[int]bool)
+	for _, f := range feeds {
+		err = Validate(f)
+		if err != nil {
+			return nil, err
+		}
+
+		if codes[f.Code] {
+			return nil, fmt.Errorf("selector feed code %d is used more than once", f.Code)
+		}
+		codes[f.Code] = true
+	}
+
+	return feeds, nil
+}
+
+// Validate function checks that a selector feed has
+// everything needed to search mangas and find chapters.
+func Validate(config models.SelectorFeedConfig) error {
+
+	if config.Code < MinFeedCode {
+		return fmt.Errorf("selector feed %q code must be %d or higher", config.Name, MinFeedCode)
+	}
+
+	if config.Name == "" {
+		return fmt.Errorf("selector feed %d has no name", config.Code)
+	}
+
+	if !strings.Contains(config.SearchURL, "%s") {
+		return fmt.Errorf("selector feed %q search_url must contain %%s", config.Name)
+	}
+
+	if config.ResultSelector == "" || config.ChapterSelector == "" {
+		return fmt.Errorf("selector feed %q needs result_selector and chapter_selector", config.Name)
+	}
+
+	// Manga and chapter URLs are built with fmt.Sprintf
+	// out of url_base, so it can't have any other verb
+	if strings.Count(config.URLBase, "%s") != 1 || strings.Count(config.URLBase, "%") != 1 {
+		return fmt.Errorf("selector feed %q url_base must contain %%s once and no other %%", config.Name)
+	}
+
+	return nil
+}
+
+// ViewManga method returns a string with
+// the Manga's URL
+func (s *SelectorFeed) ViewManga() string {
+	return s.Config.URLBase
+}
+
+// QueryManga method receives a string that refers to the Manga name, it then
+// loads the feed's search page, and with the results matching the configured
+// selector it returns a pointer to a ApiQuerySuggestions struct
+func (s *SelectorFeed) QueryManga(name string) *models.ApiQuerySuggestions {
+
+	if name == "" {
+		return nil
+	}
+
+	// Search URLs might have escaped characters, like %20,
+	// so the query isn't put in with fmt.Sprintf
+	path := strings.Replace(s.Config.SearchURL, "%s", url.QueryEscape(name), -1)
+
+	page, err := s.getPage(path)
+	if err != nil {
+		log.Println("There was an error getting the search page: ", err)
+		return nil
+	}
+
+	suggestions := new(models.ApiQuerySuggestions)
+
+	page.Find(s.Config.ResultSelector).Each(func(idx int, sel *goquery.Selection) {
+		mangaURL, _ := sel.Attr(s.Config.HrefAttr)
+		if mangaURL == "" {
+			return
+		}
+
+		title := strings.TrimSpace(sel.Text())
+		if s.Config.TitleAttr != "" {
+			title, _ = sel.Attr(s.Config.TitleAttr)
+		}
+
+		suggestions.Suggestions = append(suggestions.Suggestions, models.MangaSuggestions{
+			Data:  s.relative(mangaURL),
+			Value: title,
+		})
+	})
+
+	return suggestions
+}
+
+// GetLastMangaChapter method receives the URL to a manga title and returns
+// the first chapter matching the configured selector. An error might be
+// returned if the page can't be loaded.
+func (s *SelectorFeed) GetLastMangaChapter(mangaURL string) (string, error) {
+
+	if mangaURL == "" {
+		log.Println("No manga supplied")
+		return "", nil
+	}
+
+	page, err := s.getPage(mangaURL)
+	if err != nil {
+		log.Println("There was an error getting the manga page: ", err)
+		return "", err
+	}
+
+	lastChapter, _ := page.Find(s.Config.ChapterSelector).First().Attr(s.Config.ChapterAttr)
+	if lastChapter == "" {
+		return "", nil
+	}
+
+	lastChapter = s.relative(lastChapter)
+	if strings.HasPrefix(lastChapter, "http://") || strings.HasPrefix(lastChapter, "https://") {
+		return lastChapter, nil
+	}
+
+	return fmt.Sprintf(s.Config.URLBase, lastChapter), nil
+}
+
+// Subscribe method receives a subscription model, this contains information
+// about a User or Group that wants to receive alerts from a certain Manga title.
+// The method will save this in a 'Subscription' collection in MongoDB, as well as
+// set a value for the lastChapter of the Manga title.
+func (s *SelectorFeed) Subscribe(subscription *models.Subscription) error {
+
+	// Validate subscription data
+	if subscription.MangaName == "" || subscription.MangaURL == "" {
+		log.Println("No manga supplied for subscription")
+		return errors.New("no manga supplied for subscription")
+	}
+
+	if subscription.ChatID == 0 {
+		log.Println("No Chat supplied for subscription")
+		return errors.New("no Chat supplied for subscription")
+	}
+
+	subscription.ID = primitive.NewObjectID()
+	subscription.MangaFeed = s.Config.Code
+
+	subscription.LastChapterURL, _ = s.GetLastMangaChapter(subscription.MangaURL)
+
+	_, err := s.DB.MongoClient.Collection("subscription").InsertOne(s.DB.Ctx, subscription)
+	if err != nil && !strings.Contains(err.Error(), "subscription_unq") {
+		log.Println("There was an error creating new subscription: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// client is the HTTP client pages are loaded
+// with, which gives up on slow pages.
+var client = &http.Client{Timeout: 30 * time.Second}
+
+func (s *SelectorFeed) getPage(path string) (*goquery.Document, error) {
+	res, err := client.Get(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status code was not OK: %d", res.StatusCode)
+	}
+
+	return goquery.NewDocumentFromReader(res.Body)
+}
+
+// relative strips the URL base from absolute URLs so they
+// can be used with ViewManga like the relative ones.
+func (s *SelectorFeed) relative(href string) string {
+	prefix := strings.Split(s.Config.URLBase, "%s")[0]
+	if prefix != "" && strings.HasPrefix(href, prefix) {
+		return strings.TrimPrefix(href, prefix)
+	}
+
+	return href
+}
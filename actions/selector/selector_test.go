@@ -0,0 +1,203 @@
+package selector
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/tavomoya/mangagram/models"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func testSelectorServer() *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("keyword") == "err" {
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		fixture := "./../../test/kissmanga-read.html"
+		if strings.HasPrefix(r.URL.Path, "/Search") {
+			fixture = "./../../test/kissmanga.html"
+		}
+
+		file, _ := ioutil.ReadFile(fixture)
+		rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+		rw.WriteHeader(http.StatusOK)
+		rw.Write(file)
+	}))
+
+	return server
+}
+
+func testConfig(serverURL string) models.SelectorFeedConfig {
+	return models.SelectorFeedConfig{
+		Code:            100,
+		Name:            "Kissmanga Mirror",
+		URL:             "https://kissmanga.org",
+		SearchURL:       serverURL + "/Search/SearchSuggest?keyword=%s",
+		ResultSelector:  "a.item_search_link",
+		ChapterSelector: "div.listing div div h3 a",
+		URLBase:         "https://kissmanga.org%s",
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	is := is.New(t)
+
+	t.Run("Missing file", func(t *testing.T) {
+		_, err := LoadConfig("./../../test/missing.yaml")
+		is.True(err != nil)
+	})
+
+	t.Run("YAML file", func(t *testing.T) {
+		feeds, err := LoadConfig("./../../test/selector-feeds.yaml")
+		is.NoErr(err)
+		is.Equal(len(feeds), 1)
+		is.Equal(feeds[0].Code, 100)
+		is.Equal(feeds[0].ResultSelector, "a.item_search_link")
+	})
+
+	t.Run("JSON file", func(t *testing.T) {
+		feeds, err := LoadConfig("./../../test/selector-feeds.json")
+		is.NoErr(err)
+		is.Equal(len(feeds), 1)
+		is.Equal(feeds[0].TitleAttr, "title")
+	})
+}
+
+func TestValidate(t *testing.T) {
+	is := is.New(t)
+	config := testConfig("https://kissmanga.org")
+
+	is.NoErr(Validate(config))
+
+	t.Run("Reserved code", func(t *testing.T) {
+		c := config
+		c.Code = 5
+		is.True(Validate(c) != nil)
+	})
+
+	t.Run("Search URL without verb", func(t *testing.T) {
+		c := config
+		c.SearchURL = "https://kissmanga.org/Search"
+		is.True(Validate(c) != nil)
+	})
+
+	t.Run("Missing selectors", func(t *testing.T) {
+		c := config
+		c.ChapterSelector = ""
+		is.True(Validate(c) != nil)
+	})
+
+	t.Run("Missing URL base", func(t *testing.T) {
+		c := config
+		c.URLBase = ""
+		is.True(Validate(c) != nil)
+	})
+
+	t.Run("URL base with escapes", func(t *testing.T) {
+		c := config
+		c.URLBase = "https://kissmanga.org/read%20online%s"
+		is.True(Validate(c) != nil)
+	})
+}
+
+func TestQueryManga(t *testing.T) {
+	is := is.New(t)
+	server := testSelectorServer()
+	defer server.Close()
+
+	feed := NewSelectorFeed(testConfig(server.URL), nil)
+
+	t.Run("No manga name", func(t *testing.T) {
+		suggestions := feed.QueryManga("")
+		is.Equal(suggestions, nil)
+	})
+
+	t.Run("Search page error", func(t *testing.T) {
+		suggestions := feed.QueryManga("err")
+		is.Equal(suggestions, nil)
+	})
+
+	t.Run("Happy path", func(t *testing.T) {
+		suggestions := feed.QueryManga("Naruto")
+		is.True(suggestions != nil)
+		is.Equal(len(suggestions.Suggestions), 5)
+		is.Equal(suggestions.Suggestions[0].Value, "Naruto")
+		is.Equal(suggestions.Suggestions[0].Data, "/manga/manga-ng952689")
+	})
+
+	t.Run("Search URL with escapes", func(t *testing.T) {
+		config := testConfig(server.URL)
+		config.SearchURL = server.URL + "/Search/SearchSuggest?type=manga%20list&keyword=%s"
+		feed := NewSelectorFeed(config, nil)
+
+		is.Equal(feed.QueryManga("err"), nil)
+		is.True(feed.QueryManga("Naruto") != nil)
+	})
+}
+
+func TestGetLastMangaChapter(t *testing.T) {
+	is := is.New(t)
+	server := testSelectorServer()
+	defer server.Close()
+
+	feed := NewSelectorFeed(testConfig(server.URL), nil)
+
+	t.Run("No manga URL supplied", func(t *testing.T) {
+		url, err := feed.GetLastMangaChapter("")
+		is.Equal(url, "")
+		is.NoErr(err)
+	})
+
+	t.Run("Happy path", func(t *testing.T) {
+		url, err := feed.GetLastMangaChapter(server.URL + "/manga/manga-ng952689")
+		is.NoErr(err)
+		is.Equal(url, "https://kissmanga.org/chapter/manga-ng952689/chapter-700.5")
+	})
+}
+
+func TestSubscribe(t *testing.T) {
+	is := is.New(t)
+	opts := &mtest.Options{}
+	opts.ClientType(mtest.Mock)
+	opts.CollectionName("subscription")
+	opts.DatabaseName("mangagram")
+	opts.ShareClient(true)
+
+	mt := mtest.New(t, opts)
+	defer mt.Close()
+
+	server := testSelectorServer()
+	defer server.Close()
+
+	feed := NewSelectorFeed(testConfig(server.URL), &models.DatabaseConfig{
+		Ctx:         context.Background(),
+		MongoClient: mt.Client.Database("mangagram"),
+	})
+
+	t.Run("No manga name supplied", func(t *testing.T) {
+		err := feed.Subscribe(&models.Subscription{})
+		is.True(err != nil)
+		is.True(strings.Contains(err.Error(), "no manga supplied"))
+	})
+
+	t.Run("Happy path", func(t *testing.T) {
+		sub := &models.Subscription{
+			MangaName: "Naruto",
+			MangaURL:  server.URL + "/manga/manga-ng952689",
+			ChatID:    10,
+		}
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+
+		err := feed.Subscribe(sub)
+		is.NoErr(err)
+		is.Equal(sub.MangaFeed, 100)
+	})
+}
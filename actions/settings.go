@@ -0,0 +1,159 @@
+package actions
+
+import (
+	"errors"
+	"log"
+
+	"github.com/tavomoya/mangagram/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// NotificationFormats defines the formats available for new
+// chapter alerts. The custom format uses the chat's own template.
+var NotificationFormats = []string{"default", "compact", "rich", "custom"}
+
+// DeliveryModes defines the ways new chapter
+// alerts can be delivered to a chat.
+var DeliveryModes = []string{"instant", "daily", "weekly"}
+
+// DefaultDigestHour is the hour digests are
+// sent at when a chat starts using them.
+const DefaultDigestHour = 9
+
+// DefaultTimezone is the timezone used for
+// chats that haven't chosen one.
+const DefaultTimezone = "UTC"
+
+// DefaultChatSettings function returns the settings
+// used for chats that haven't changed any of them.
+func DefaultChatSettings(chatID int64) *models.ChatSettings {
+	return &models.ChatSettings{
+		ChatID:    chatID,
+		Feed:      AvailableFeeds[0].Code,
+		Languages: DefaultLanguages,
+		Format:    NotificationFormats[0],
+		Timezone:  DefaultTimezone,
+		Delivery:  DeliveryModes[0],
+		ManagedBy: ManageModes[0],
+	}
+}
+
+// GetChatSettings method returns the settings of a Chat. Settings the chat
+// hasn't changed are filled with their defaults, and the default settings are
+// returned if the chat has none or the query fails, so it never returns nil.
+func GetChatSettings(db *models.DatabaseConfig, chatID int64) *models.ChatSettings {
+
+	defaults := DefaultChatSettings(chatID)
+
+	if db == nil {
+		log.Println("The DB model is nil")
+		return defaults
+	}
+
+	settings := new(models.ChatSettings)
+	res := db.MongoClient.Collection("feed_sub").FindOne(db.Ctx, bson.M{"chatid": chatID})
+	err := res.Decode(settings)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Println("There was an unexpected error decoding feed_sub document into a struct: ", err)
+		}
+		return defaults
+	}
+
+	if settings.Feed == 0 {
+		settings.Feed = defaults.Feed
+	}
+
+	if len(settings.Languages) == 0 {
+		settings.Languages = defaults.Languages
+	}
+
+	if settings.Format == "" {
+		settings.Format = defaults.Format
+	}
+
+	if settings.Timezone == "" {
+		settings.Timezone = defaults.Timezone
+	}
+
+	if settings.Delivery == "" {
+		settings.Delivery = defaults.Delivery
+	}
+
+	if settings.ManagedBy == "" {
+		settings.ManagedBy = defaults.ManagedBy
+	}
+
+	return settings
+}
+
+// SetChatSetting method saves a single setting of a Chat, using the setting's
+// field name in the feed_sub document (e.g. "format"). Chats without a feed
+// subscription get one for the default feed.
+func SetChatSetting(db *models.DatabaseConfig, chatID int64, field string, value interface{}) error {
+
+	if db == nil {
+		log.Println("The DB model is nil")
+		return errors.New("the DB model passed is nil, can't operate")
+	}
+
+	if chatID == 0 || field == "" {
+		log.Println("No chat or setting supplied")
+		return errors.New("no chat or setting supplied")
+	}
+
+	_, err := db.MongoClient.Collection("feed_sub").UpdateOne(
+		db.Ctx,
+		bson.M{"chatid": chatID},
+		bson.M{
+			"$set":         bson.M{field: value},
+			"$setOnInsert": bson.M{"code": AvailableFeeds[0].Code, "url": AvailableFeeds[0].URL},
+		},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		log.Println("There was an error saving the chat setting: ", field, err)
+		return err
+	}
+
+	return nil
+}
+
+// GetChatLanguages method returns the chapter languages a Chat prefers, in order.
+// If the chat hasn't chosen any languages it returns DefaultLanguages.
+func GetChatLanguages(db *models.DatabaseConfig, chatID int64) []string {
+	return GetChatSettings(db, chatID).Languages
+}
+
+// SetChatLanguages method saves the chapter languages a Chat prefers.
+// It returns an error if no languages are supplied.
+func SetChatLanguages(db *models.DatabaseConfig, chatID int64, langs []string) error {
+
+	if len(langs) == 0 {
+		log.Println("No languages supplied")
+		return errors.New("no languages supplied")
+	}
+
+	return SetChatSetting(db, chatID, "languages", langs)
+}
+
+// GetChatLocale method returns the locale chosen for a Chat's messages.
+// It returns an empty string if the chat hasn't chosen one.
+func GetChatLocale(db *models.DatabaseConfig, chatID int64) string {
+	return GetChatSettings(db, chatID).Locale
+}
+
+// SetChatLocale method saves the locale used for a Chat's messages.
+// It returns an error if no locale is supplied.
+func SetChatLocale(db *models.DatabaseConfig, chatID int64, locale string) error {
+
+	if locale == "" {
+		log.Println("No locale supplied")
+		return errors.New("no locale supplied")
+	}
+
+	return SetChatSetting(db, chatID, "locale", locale)
+}
@@ -0,0 +1,133 @@
+package actions
+
+import (
+	"context"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/tavomoya/mangagram/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestGetChatSettings(t *testing.T) {
+	opts := &mtest.Options{}
+	opts.ClientType(mtest.Mock)
+	opts.CollectionName("feed_sub")
+	opts.DatabaseName("mangagram")
+	opts.ShareClient(true)
+
+	mt := mtest.New(t, opts)
+	defer mt.Close()
+
+	is := is.New(t)
+	config := &models.DatabaseConfig{
+		Ctx:         context.Background(),
+		MongoClient: mt.Client.Database("mangagram"),
+	}
+
+	mt.Run("Nil Database", func(t *mtest.T) {
+		settings := GetChatSettings(nil, 1)
+		is.Equal(settings, DefaultChatSettings(1))
+	})
+
+	mt.Run("Failed to query", func(t *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCommandErrorResponse(mtest.CommandError{
+			Code: 90,
+		}))
+
+		settings := GetChatSettings(config, 1)
+		is.Equal(settings, DefaultChatSettings(1))
+	})
+
+	mt.Run("No settings saved", func(t *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "feed_sub.chatid", mtest.FirstBatch))
+
+		settings := GetChatSettings(config, 1)
+		is.Equal(settings, DefaultChatSettings(1))
+	})
+
+	mt.Run("Defaults for missing settings", func(t *mtest.T) {
+		id := primitive.NewObjectID()
+		mt.AddMockResponses(mtest.CreateCursorResponse(1, "feed_sub.chatid", mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: id},
+			{Key: "chatid", Value: int64(1)},
+			{Key: "code", Value: 5},
+			{Key: "locale", Value: "es"},
+			{Key: "quietstart", Value: 22},
+			{Key: "quietend", Value: 7},
+			{Key: "digesthour", Value: 18},
+		}))
+
+		settings := GetChatSettings(config, 1)
+		is.Equal(settings, &models.ChatSettings{
+			ID:         id,
+			ChatID:     1,
+			Feed:       5,
+			Locale:     "es",
+			Languages:  DefaultLanguages,
+			Format:     "default",
+			QuietStart: 22,
+			QuietEnd:   7,
+			Timezone:   DefaultTimezone,
+			Delivery:   "instant",
+			DigestHour: 18,
+			ManagedBy:  "admins",
+		})
+	})
+}
+
+func TestSetChatSetting(t *testing.T) {
+	opts := &mtest.Options{}
+	opts.ClientType(mtest.Mock)
+	opts.CollectionName("feed_sub")
+	opts.DatabaseName("mangagram")
+	opts.ShareClient(true)
+
+	mt := mtest.New(t, opts)
+	defer mt.Close()
+
+	is := is.New(t)
+	config := &models.DatabaseConfig{
+		Ctx:         context.Background(),
+		MongoClient: mt.Client.Database("mangagram"),
+	}
+
+	mt.Run("Nil Database", func(t *mtest.T) {
+		err := SetChatSetting(nil, 1, "format", "compact")
+		is.True(err != nil)
+	})
+
+	mt.Run("Invalid chat ID", func(t *mtest.T) {
+		err := SetChatSetting(config, 0, "format", "compact")
+		is.True(err != nil)
+	})
+
+	mt.Run("Failed to update", func(t *mtest.T) {
+		mt.AddMockResponses(mtest.CreateWriteErrorsResponse(mtest.WriteError{
+			Code:    11000,
+			Message: "something went wrong",
+		}))
+
+		err := SetChatSetting(config, 1, "format", "compact")
+		is.True(err != nil)
+	})
+
+	mt.Run("Success", func(t *mtest.T) {
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}})
+
+		err := SetChatSetting(config, 1, "format", "compact")
+		is.NoErr(err)
+	})
+
+	mt.Run("No languages", func(t *mtest.T) {
+		err := SetChatLanguages(config, 1, nil)
+		is.True(err != nil)
+	})
+
+	mt.Run("No locale", func(t *mtest.T) {
+		err := SetChatLocale(config, 1, "")
+		is.True(err != nil)
+	})
+}
@@ -3,6 +3,7 @@ package actions
 import (
 	"errors"
 	"log"
+	"time"
 
 	"github.com/tavomoya/mangagram/models"
 
@@ -11,6 +12,14 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// UndoWindow is how long a removed subscription
+// can be restored before it's deleted for good.
+const UndoWindow = 10 * time.Minute
+
+// ErrUndoExpired is returned when a subscription is restored
+// after UndoWindow, or after it was deleted for good.
+var ErrUndoExpired = errors.New("the subscription can't be restored anymore")
+
 // GetChatSubscriptions method returns a slice of subscriptions attached to a specific chat ID.
 // This receives a DatabaseConfig struct and a chatID parameter. It might return an error if
 // the DatabaseConfig parameter is nil or if any error is returned by querying the database.
@@ -21,7 +30,7 @@ func GetChatSubscriptions(db *models.DatabaseConfig, chatID int64) ([]*models.Su
 		return nil, errors.New("the DB model passed is nil, can't operate")
 	}
 
-	cursor, err := db.MongoClient.Collection("subscription").Find(db.Ctx, bson.M{"chatid": chatID})
+	cursor, err := db.MongoClient.Collection("subscription").Find(db.Ctx, bson.M{"chatid": chatID, "deleted_at": bson.M{"$exists": false}})
 	if err != nil {
 		log.Println("There was an error trying to look for this chat's subscriptions: ", err)
 		return nil, err
@@ -37,9 +46,36 @@ func GetChatSubscriptions(db *models.DatabaseConfig, chatID int64) ([]*models.Su
 	return subs, nil
 }
 
-// RemoveMangaSubscription method deletes a subscription using the ID of said subscription.
-// This receives a DatabaseConfig struct and a subscriptionID parameter. It might return an error if
-// the DatabaseConfig parameter is nil or if any error is returned by querying the database.
+// GetSubscription method returns a subscription by its ID, removed ones
+// included (see DeletedAt). It might return an error if the DatabaseConfig
+// parameter is nil, the ID is not valid or there's no subscription with it.
+func GetSubscription(db *models.DatabaseConfig, subscriptionID string) (*models.Subscription, error) {
+
+	if db == nil {
+		log.Println("The DB model is nil")
+		return nil, errors.New("the DB model passed is nil, can't operate")
+	}
+
+	id, err := primitive.ObjectIDFromHex(subscriptionID)
+	if err != nil {
+		log.Println("Invalid subscription ID: ", subscriptionID)
+		return nil, err
+	}
+
+	sub := new(models.Subscription)
+	err = db.MongoClient.Collection("subscription").FindOne(db.Ctx, bson.M{"_id": id}).Decode(sub)
+	if err != nil {
+		log.Println("There was an error looking for the subscription: ", err)
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+// RemoveMangaSubscription method removes a subscription using the ID of said subscription.
+// The subscription is only marked as deleted, so it can be restored with RestoreSubscription
+// for UndoWindow. This receives a DatabaseConfig struct and a subscriptionID parameter. It might
+// return an error if the DatabaseConfig parameter is nil or if any error is returned by querying the database.
 func RemoveMangaSubscription(db *models.DatabaseConfig, subscriptionID string) error {
 
 	if db == nil {
@@ -53,20 +89,100 @@ func RemoveMangaSubscription(db *models.DatabaseConfig, subscriptionID string) e
 		return err
 	}
 
-	res, err := db.MongoClient.Collection("subscription").DeleteOne(db.Ctx, bson.M{"_id": id})
+	res, err := db.MongoClient.Collection("subscription").UpdateOne(
+		db.Ctx,
+		bson.M{"_id": id, "deleted_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"deleted_at": time.Now()}},
+	)
 	if err != nil {
 		log.Println("There was an error trying to remove subscription: ", err)
 		return err
 	}
 
-	if res.DeletedCount != 1 {
-		log.Println("Couldn't delete subscription: ", res.DeletedCount)
+	if res.MatchedCount != 1 {
+		log.Println("Couldn't delete subscription: ", res.MatchedCount)
 		return errors.New("An unexpected error happened and the subscription was not deleted.")
 	}
 
 	return nil
 }
 
+// RestoreSubscription method restores a subscription removed with
+// RemoveMangaSubscription. It returns ErrUndoExpired if it was
+// removed longer than UndoWindow ago.
+func RestoreSubscription(db *models.DatabaseConfig, subscriptionID string) error {
+
+	if db == nil {
+		log.Println("The DB model is nil")
+		return errors.New("the DB model passed is nil, can't operate")
+	}
+
+	id, err := primitive.ObjectIDFromHex(subscriptionID)
+	if err != nil {
+		log.Println("Invalid subscription ID: ", subscriptionID)
+		return err
+	}
+
+	res, err := db.MongoClient.Collection("subscription").UpdateOne(
+		db.Ctx,
+		bson.M{"_id": id, "deleted_at": bson.M{"$gte": time.Now().Add(-UndoWindow)}},
+		bson.M{"$unset": bson.M{"deleted_at": ""}},
+	)
+	if err != nil {
+		log.Println("There was an error restoring the subscription: ", err)
+		return err
+	}
+
+	if res.MatchedCount != 1 {
+		return ErrUndoExpired
+	}
+
+	return nil
+}
+
+// PurgeRemovedSubscriptions method deletes for good the subscriptions
+// removed longer than UndoWindow ago. It returns how many were deleted.
+func PurgeRemovedSubscriptions(db *models.DatabaseConfig) (int64, error) {
+
+	if db == nil {
+		log.Println("The DB model is nil")
+		return 0, errors.New("the DB model passed is nil, can't operate")
+	}
+
+	res, err := db.MongoClient.Collection("subscription").DeleteMany(
+		db.Ctx,
+		bson.M{"deleted_at": bson.M{"$lt": time.Now().Add(-UndoWindow)}},
+	)
+	if err != nil {
+		log.Println("There was an error purging removed subscriptions: ", err)
+		return 0, err
+	}
+
+	return res.DeletedCount, nil
+}
+
+// ClearRemovedSubscription method deletes for good a Chat's removed
+// subscription to a title, if it has one, so the chat can subscribe
+// to the title again while the removed one could still be restored.
+func ClearRemovedSubscription(db *models.DatabaseConfig, chatID int64, mangaURL string) error {
+
+	if db == nil {
+		log.Println("The DB model is nil")
+		return errors.New("the DB model passed is nil, can't operate")
+	}
+
+	_, err := db.MongoClient.Collection("subscription").DeleteMany(
+		db.Ctx,
+		bson.M{"chatid": chatID, "mangaurl": mangaURL, "deleted_at": bson.M{"$exists": true}},
+	)
+	if err != nil {
+		log.Println("There was an error clearing the removed subscription: ", err)
+		return err
+	}
+
+	return nil
+}
+
 // GetChatMangaFeed method returns the Manga Feed code that a Chat is
 // currently cubscribed to. If the chat is not subscribed to any feeds
 // the method defaults to feed 1 (Manga Reader).
@@ -127,6 +127,40 @@ func TestRemoveMangaSubscription(t *testing.T) {
 	})
 }
 
+func TestRestoreSubscription(t *testing.T) {
+	opts := &mtest.Options{}
+	opts.ClientType(mtest.Mock)
+	opts.CollectionName("subscription")
+	opts.DatabaseName("mangagram")
+	opts.ShareClient(true)
+
+	mt := mtest.New(t, opts)
+	defer mt.Close()
+
+	is := is.New(t)
+	config := &models.DatabaseConfig{
+		Ctx:         context.Background(),
+		MongoClient: mt.Client.Database("mangagram"),
+	}
+
+	mt.Run("Nil Database", func(t *mtest.T) {
+		err := RestoreSubscription(nil, "60fc82d3188b85f46f5f6b9c")
+		is.True(err != nil)
+	})
+
+	mt.Run("Removed too long ago", func(t *mtest.T) {
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 0}})
+		err := RestoreSubscription(config, "60fc82d3188b85f46f5f6b9c")
+		is.Equal(err, ErrUndoExpired)
+	})
+
+	mt.Run("Success", func(t *mtest.T) {
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}})
+		err := RestoreSubscription(config, "60fc82d3188b85f46f5f6b9c")
+		is.NoErr(err)
+	})
+}
+
 func TestGetChatMangaFeed(t *testing.T) {
 	opts := &mtest.Options{}
 	opts.ClientType(mtest.Mock)
@@ -0,0 +1,255 @@
+package tachiyomi
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// MaxBackupSize is the size, in bytes, of the largest backup file
+// that can be read, which is the most a bot can download.
+const MaxBackupSize = 20 << 20
+
+// maxUncompressedSize is the size, in bytes, of the largest
+// backup once uncompressed, so bad files don't eat the memory.
+const maxUncompressedSize = 100 << 20
+
+// Field numbers of the Backup and BackupManga protobuf messages
+// of the Tachiyomi and Mihon apps, the rest are ignored.
+const (
+	backupMangaField   = 1
+	mangaSourceField   = 1
+	mangaURLField      = 2
+	mangaTitleField    = 3
+	mangaAuthorField   = 5
+	mangaFavoriteField = 100
+)
+
+// Protobuf wire types.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// ErrInvalidBackup is returned when a file
+// is not a Tachiyomi or Mihon backup.
+var ErrInvalidBackup = errors.New("the file is not a Tachiyomi backup")
+
+// Manga is a title of the library in a backup.
+type Manga struct {
+	// ID of the app's source the title is read from
+	Source int64
+
+	// URL of the title in the source, usually relative
+	URL string
+
+	// Title of the manga
+	Title string
+
+	// Author of the manga, might be empty
+	Author string
+
+	// Whether the title is in the library. Backups
+	// might have titles that were only read
+	Favorite bool
+}
+
+// IsBackupFile function reports whether a
+// file name is the one of a backup file.
+func IsBackupFile(name string) bool {
+	name = strings.ToLower(name)
+	return strings.HasSuffix(name, ".tachibk") || strings.HasSuffix(name, ".proto.gz")
+}
+
+// ParseBackup function reads the library of a backup file made by
+// Tachiyomi or one of its forks like Mihon, a gzip'd protobuf Backup
+// message. Only the titles in the library are returned. It returns
+// ErrInvalidBackup if the file can't be read.
+func ParseBackup(r io.Reader) ([]Manga, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, ErrInvalidBackup
+	}
+	defer gz.Close()
+
+	data, err := ioutil.ReadAll(io.LimitReader(gz, maxUncompressedSize+1))
+	if err != nil || len(data) > maxUncompressedSize {
+		return nil, ErrInvalidBackup
+	}
+
+	library := []Manga{}
+
+	d := &decoder{data: data}
+	for !d.done() {
+		field, wire, err := d.key()
+		if err != nil {
+			return nil, err
+		}
+
+		if field != backupMangaField || wire != wireBytes {
+			err = d.skip(wire)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		msg, err := d.bytes()
+		if err != nil {
+			return nil, err
+		}
+
+		manga, err := parseManga(msg)
+		if err != nil {
+			return nil, err
+		}
+
+		if manga.Favorite && manga.Title != "" {
+			library = append(library, *manga)
+		}
+	}
+
+	return library, nil
+}
+
+// parseManga reads a BackupManga message.
+func parseManga(msg []byte) (*Manga, error) {
+	// Titles are in the library unless they say otherwise
+	manga := &Manga{Favorite: true}
+
+	d := &decoder{data: msg}
+	for !d.done() {
+		field, wire, err := d.key()
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case field == mangaSourceField && wire == wireVarint:
+			v, err := d.varint()
+			if err != nil {
+				return nil, err
+			}
+			manga.Source = int64(v)
+
+		case field == mangaFavoriteField && wire == wireVarint:
+			v, err := d.varint()
+			if err != nil {
+				return nil, err
+			}
+			manga.Favorite = v != 0
+
+		case (field == mangaURLField || field == mangaTitleField || field == mangaAuthorField) && wire == wireBytes:
+			v, err := d.bytes()
+			if err != nil {
+				return nil, err
+			}
+
+			switch field {
+			case mangaURLField:
+				manga.URL = string(v)
+			case mangaTitleField:
+				manga.Title = strings.TrimSpace(string(v))
+			case mangaAuthorField:
+				manga.Author = string(v)
+			}
+
+		default:
+			err = d.skip(wire)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return manga, nil
+}
+
+// decoder reads the fields of a protobuf message.
+type decoder struct {
+	data []byte
+	pos  int
+}
+
+// done reports whether the whole message was read.
+func (d *decoder) done() bool {
+	return d.pos >= len(d.data)
+}
+
+// key reads the number and wire type of the next field.
+func (d *decoder) key() (int, int, error) {
+	v, err := d.varint()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return int(v >> 3), int(v & 7), nil
+}
+
+// varint reads a base 128 varint.
+func (d *decoder) varint() (uint64, error) {
+	var v uint64
+	for shift := uint(0); shift < 64; shift += 7 {
+		if d.done() {
+			return 0, ErrInvalidBackup
+		}
+
+		b := d.data[d.pos]
+		d.pos++
+
+		v |= uint64(b&0x7f) << shift
+		if b < 0x80 {
+			return v, nil
+		}
+	}
+
+	return 0, ErrInvalidBackup
+}
+
+// bytes reads a length-delimited field.
+func (d *decoder) bytes() ([]byte, error) {
+	n, err := d.varint()
+	if err != nil {
+		return nil, err
+	}
+
+	if n > uint64(len(d.data)-d.pos) {
+		return nil, ErrInvalidBackup
+	}
+
+	v := d.data[d.pos : d.pos+int(n)]
+	d.pos += int(n)
+
+	return v, nil
+}
+
+// skip reads a field of a wire type without keeping it.
+func (d *decoder) skip(wire int) error {
+	var err error
+
+	switch wire {
+	case wireVarint:
+		_, err = d.varint()
+	case wireBytes:
+		_, err = d.bytes()
+	case wireFixed64, wireFixed32:
+		size := 8
+		if wire == wireFixed32 {
+			size = 4
+		}
+
+		if len(d.data)-d.pos < size {
+			return ErrInvalidBackup
+		}
+		d.pos += size
+	default:
+		// Groups are deprecated and not used by backups
+		return ErrInvalidBackup
+	}
+
+	return err
+}
@@ -0,0 +1,97 @@
+package tachiyomi
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+// varint encodes a base 128 varint.
+func varint(v uint64) []byte {
+	b := []byte{}
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+
+	return append(b, byte(v))
+}
+
+// field encodes a length-delimited field.
+func field(num int, v []byte) []byte {
+	b := append(varint(uint64(num<<3|wireBytes)), varint(uint64(len(v)))...)
+	return append(b, v...)
+}
+
+// number encodes a varint field.
+func number(num int, v uint64) []byte {
+	return append(varint(uint64(num<<3|wireVarint)), varint(v)...)
+}
+
+// backupManga encodes a BackupManga message.
+func backupManga(source uint64, url, title string, favorite bool, extra ...[]byte) []byte {
+	msg := number(mangaSourceField, source)
+	msg = append(msg, field(mangaURLField, []byte(url))...)
+	msg = append(msg, field(mangaTitleField, []byte(title))...)
+
+	for _, e := range extra {
+		msg = append(msg, e...)
+	}
+
+	if !favorite {
+		msg = append(msg, number(mangaFavoriteField, 0)...)
+	}
+
+	return msg
+}
+
+// gzipped compresses data.
+func gzipped(data []byte) *bytes.Buffer {
+	buf := &bytes.Buffer{}
+	w := gzip.NewWriter(buf)
+	w.Write(data)
+	w.Close()
+
+	return buf
+}
+
+func TestParseBackup(t *testing.T) {
+	is := is.New(t)
+
+	chapter := field(1, []byte("/chapter/1"))
+	backup := field(backupMangaField, backupManga(2499283573021220255, "/manga/one-piece", "One Piece", true,
+		field(mangaAuthorField, []byte("Oda Eiichiro")),
+		field(16, chapter),
+		[]byte{0x9d, 0x01, 0, 0, 0x80, 0x3f}, // chapterNumber float, field 19
+	))
+	backup = append(backup, field(backupMangaField, backupManga(1, "/manga/naruto", "Naruto", false))...)
+	backup = append(backup, field(2, field(1, []byte("Reading")))...) // a category
+
+	library, err := ParseBackup(gzipped(backup))
+	is.NoErr(err)
+	is.Equal(library, []Manga{{
+		Source:   2499283573021220255,
+		URL:      "/manga/one-piece",
+		Title:    "One Piece",
+		Author:   "Oda Eiichiro",
+		Favorite: true,
+	}})
+
+	// Not gzip'd
+	_, err = ParseBackup(bytes.NewReader(backup))
+	is.Equal(err, ErrInvalidBackup)
+
+	// Truncated
+	_, err = ParseBackup(gzipped(backup[:10]))
+	is.Equal(err, ErrInvalidBackup)
+}
+
+func TestIsBackupFile(t *testing.T) {
+	is := is.New(t)
+
+	is.True(IsBackupFile("com.mihon.app_2024-05-01_10-00.tachibk"))
+	is.True(IsBackupFile("tachiyomi_2021-01-01.proto.GZ"))
+	is.True(!IsBackupFile("mangagram-1.json"))
+}
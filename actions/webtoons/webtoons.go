@@ -0,0 +1,312 @@
+package webtoons
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tavomoya/mangagram/models"
+
+	"github.com/PuerkitoBio/goquery"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxPages is the maximum number of episode list
+// pages loaded for a single title.
+const maxPages = 100
+
+// Webtoons is a struct used to attach
+// all functionality available within this
+// manga source
+type Webtoons struct {
+	DB           *models.DatabaseConfig
+	ApiURL       string
+	ViewMangaURL string
+}
+
+// NewWebtoons function returns a pointer to a Webtoons
+// struct that can be used to call all of its methods
+func NewWebtoons(db *models.DatabaseConfig) *Webtoons {
+	return &Webtoons{
+		DB:           db,
+		ApiURL:       "https://www.webtoons.com/en/search?keyword=%s",
+		ViewMangaURL: "https://www.webtoons.com%s",
+	}
+}
+
+// ViewManga method returns a string with
+// the Manga's URL
+func (w *Webtoons) ViewManga() string {
+	return w.ViewMangaURL
+}
+
+// QueryManga method receives a string that refers to the title name, it then
+// loads the Webtoons search page, and with the results it returns a
+// pointer to a ApiQuerySuggestions struct
+func (w *Webtoons) QueryManga(name string) *models.ApiQuerySuggestions {
+
+	if name == "" {
+		return nil
+	}
+
+	path := fmt.Sprintf(w.ApiURL, url.QueryEscape(name))
+
+	page, err := getPage(path)
+	if err != nil {
+		log.Println("There was an error getting Webtoons search page: ", err)
+		return nil
+	}
+
+	suggestions := new(models.ApiQuerySuggestions)
+
+	page.Find("ul.card_lst li a.card_item").Each(func(idx int, s *goquery.Selection) {
+		titleURL, _ := s.Attr("href")
+
+		// Search results link to the absolute URL, strip
+		// the host so it can be used with ViewMangaURL
+		if u, err := url.Parse(titleURL); err == nil && u.IsAbs() {
+			titleURL = u.RequestURI()
+		}
+
+		suggestions.Suggestions = append(suggestions.Suggestions, models.MangaSuggestions{
+			Data:     titleURL,
+			Value:    strings.TrimSpace(s.Find("p.subj").Text()),
+			Language: "en",
+		})
+	})
+
+	return suggestions
+}
+
+// GetLastMangaChapter method receives the URL to a title and returns
+// the latest episode published, which is the first one in the first
+// page of the episode list. An error might be returned if
+// the page cannot be loaded
+func (w *Webtoons) GetLastMangaChapter(titleURL string) (string, error) {
+
+	episode, err := w.GetLastChapter(titleURL)
+	if err != nil || episode == nil {
+		return "", err
+	}
+
+	return episode.URL, nil
+}
+
+// GetLastChapter method receives the URL to a title and returns its
+// latest episode, with its number and title, or nil if it has none.
+// An error might be returned if the page cannot be loaded
+func (w *Webtoons) GetLastChapter(titleURL string) (*models.MangaChapter, error) {
+
+	if titleURL == "" {
+		log.Println("No title supplied")
+		return nil, nil
+	}
+
+	episodes, _, err := w.episodePage(titleURL, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(episodes) == 0 {
+		return nil, nil
+	}
+
+	return &episodes[0], nil
+}
+
+// GetEpisodes method receives the URL to a title and returns every
+// episode in its paginated episode list, newest first. An error
+// might be returned if any of the pages cannot be loaded
+func (w *Webtoons) GetEpisodes(titleURL string) ([]models.MangaChapter, error) {
+
+	if titleURL == "" {
+		log.Println("No title supplied")
+		return nil, errors.New("no title supplied")
+	}
+
+	episodes := make([]models.MangaChapter, 0)
+
+	for p := 1; p <= maxPages; p++ {
+		list, last, err := w.episodePage(titleURL, p)
+		if err != nil {
+			return nil, err
+		}
+
+		episodes = append(episodes, list...)
+
+		if p >= last {
+			break
+		}
+	}
+
+	return episodes, nil
+}
+
+// GetMangaInfo method receives the URL to a title and returns its
+// title, cover, author, genre, description and latest episodes, taken
+// from the first page of its episode list. An error might be returned
+// if no URL is supplied or if the page cannot be loaded
+func (w *Webtoons) GetMangaInfo(titleURL string) (*models.MangaInfo, error) {
+
+	if titleURL == "" {
+		log.Println("No title supplied")
+		return nil, errors.New("no title supplied")
+	}
+
+	page, err := getPage(titleURL)
+	if err != nil {
+		log.Println("There was an error getting the title page: ", err)
+		return nil, err
+	}
+
+	header := page.Find(".detail_header .info")
+
+	info := &models.MangaInfo{
+		URL:         titleURL,
+		Feed:        models.FeedWebtoons,
+		Title:       strings.TrimSpace(header.Find("h1.subj").Text()),
+		Author:      strings.Join(strings.Fields(header.Find(".author_area").Text()), " "),
+		Description: strings.TrimSpace(page.Find("p.summary").First().Text()),
+	}
+
+	info.Cover, _ = page.Find(`meta[property="og:image"]`).Attr("content")
+
+	if genre := strings.TrimSpace(header.Find("h2.genre").Text()); genre != "" {
+		info.Genres = []string{genre}
+	}
+
+	// Ongoing titles show their update day instead
+	if strings.Contains(strings.ToLower(page.Find("p.day_info").Text()), "completed") {
+		info.Status = "Completed"
+	} else {
+		info.Status = "Ongoing"
+	}
+
+	info.Chapters = parseEpisodes(page)
+
+	return info, nil
+}
+
+// Subscribe method receives a subscription model, this contains information
+// about a User or Group that wants to receive alerts from a certain Manga title.
+// The method will save this in a 'Subscription' collection in MongoDB, as well as
+// set a value for the lastChapter of the Manga title.
+func (w *Webtoons) Subscribe(subscription *models.Subscription) error {
+
+	// Validate subscription data
+	if subscription.MangaName == "" || subscription.MangaURL == "" {
+		log.Println("No manga supplied for subscription")
+		return errors.New("no manga supplied for subscription")
+	}
+
+	if subscription.ChatID == 0 {
+		log.Println("No Chat supplied for subscription")
+		return errors.New("no Chat supplied for subscription")
+	}
+
+	subscription.ID = primitive.NewObjectID()
+	subscription.MangaFeed = models.FeedWebtoons
+
+	subscription.LastChapterURL, _ = w.GetLastMangaChapter(subscription.MangaURL)
+
+	_, err := w.DB.MongoClient.Collection("subscription").InsertOne(w.DB.Ctx, subscription)
+	if err != nil && !strings.Contains(err.Error(), "subscription_unq") {
+		log.Println("There was an error creating new subscription: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// episodePage loads a single page of a title's episode list. Besides
+// the episodes it returns the highest page number in the paginator.
+func (w *Webtoons) episodePage(titleURL string, p int) ([]models.MangaChapter, int, error) {
+	u, err := url.Parse(titleURL)
+	if err != nil {
+		log.Println("Invalid title URL: ", titleURL)
+		return nil, 0, err
+	}
+
+	q := u.Query()
+	q.Set("page", strconv.Itoa(p))
+	u.RawQuery = q.Encode()
+
+	page, err := getPage(u.String())
+	if err != nil {
+		log.Println("There was an error getting the episode list: ", err)
+		return nil, 0, err
+	}
+
+	episodes := parseEpisodes(page)
+
+	last := p
+	page.Find("div.paginate a").Each(func(idx int, s *goquery.Selection) {
+		href, _ := s.Attr("href")
+		link, err := url.Parse(href)
+		if err != nil {
+			return
+		}
+
+		n, err := strconv.Atoi(link.Query().Get("page"))
+		if err == nil && n > last {
+			last = n
+		}
+	})
+
+	return episodes, last, nil
+}
+
+// parseEpisodes returns the episodes in a
+// page of a title's episode list.
+func parseEpisodes(page *goquery.Document) []models.MangaChapter {
+	episodes := make([]models.MangaChapter, 0)
+	page.Find("ul#_listUl li._episodeItem a").Each(func(idx int, s *goquery.Selection) {
+		href, _ := s.Attr("href")
+		if href == "" {
+			return
+		}
+
+		number := ""
+		if link, err := url.Parse(href); err == nil {
+			number = link.Query().Get("episode_no")
+		}
+
+		episodes = append(episodes, models.MangaChapter{
+			Title:    strings.TrimSpace(s.Find("span.subj").Text()),
+			Number:   number,
+			URL:      href,
+			Language: "en",
+		})
+	})
+
+	return episodes
+}
+
+// client is the HTTP client pages are loaded with, which gives up
+// on slow pages so listing many of them can't hang the updates.
+var client = &http.Client{Timeout: 30 * time.Second}
+
+func getPage(path string) (*goquery.Document, error) {
+	req, err := http.NewRequest("GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status code was not OK: %d", res.StatusCode)
+	}
+
+	return goquery.NewDocumentFromReader(res.Body)
+}
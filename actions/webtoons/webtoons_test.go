@@ -0,0 +1,226 @@
+package webtoons
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/tavomoya/mangagram/models"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func testSearchServer() *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("keyword") == "err" {
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		file, _ := ioutil.ReadFile("./../../test/webtoons-search.html")
+		rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+		rw.WriteHeader(http.StatusOK)
+		rw.Write(file)
+	}))
+
+	return server
+}
+
+func testListServer() *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		fixture := "./../../test/webtoons-list.html"
+		if r.URL.Query().Get("page") == "2" {
+			fixture = "./../../test/webtoons-list-2.html"
+		}
+
+		file, _ := ioutil.ReadFile(fixture)
+		rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+		rw.WriteHeader(http.StatusOK)
+		rw.Write(file)
+	}))
+
+	return server
+}
+
+func TestViewManga(t *testing.T) {
+	is := is.New(t)
+	manga := NewWebtoons(nil)
+
+	url := manga.ViewManga()
+	is.Equal(url, manga.ViewMangaURL)
+}
+
+func TestQueryManga(t *testing.T) {
+	is := is.New(t)
+	manga := NewWebtoons(nil)
+	server := testSearchServer()
+	defer server.Close()
+
+	manga.ApiURL = server.URL + "/en/search?keyword=%s"
+
+	t.Run("No manga name", func(t *testing.T) {
+		suggestions := manga.QueryManga("")
+		is.Equal(suggestions, nil)
+	})
+
+	t.Run("Search page error", func(t *testing.T) {
+		suggestions := manga.QueryManga("err")
+		is.Equal(suggestions, nil)
+	})
+
+	t.Run("Happy path", func(t *testing.T) {
+		suggestions := manga.QueryManga("tower of god")
+		is.True(suggestions != nil)
+		is.Equal(len(suggestions.Suggestions), 2)
+		is.Equal(suggestions.Suggestions[0].Value, "Tower of God")
+		is.Equal(suggestions.Suggestions[0].Data, "/en/fantasy/tower-of-god/list?title_no=95")
+	})
+}
+
+func TestGetLastMangaChapter(t *testing.T) {
+	is := is.New(t)
+	manga := NewWebtoons(nil)
+	server := testListServer()
+	defer server.Close()
+
+	t.Run("No title URL supplied", func(t *testing.T) {
+		url, err := manga.GetLastMangaChapter("")
+		is.Equal(url, "")
+		is.NoErr(err)
+	})
+
+	t.Run("Happy path", func(t *testing.T) {
+		expect := "https://www.webtoons.com/en/fantasy/tower-of-god/season-3-ep-550/viewer?title_no=95&episode_no=550"
+		url, err := manga.GetLastMangaChapter(server.URL + "/en/fantasy/tower-of-god/list?title_no=95")
+		is.Equal(url, expect)
+		is.NoErr(err)
+	})
+}
+
+func TestGetLastChapter(t *testing.T) {
+	is := is.New(t)
+	manga := NewWebtoons(nil)
+	server := testListServer()
+	defer server.Close()
+
+	episode, err := manga.GetLastChapter(server.URL + "/en/fantasy/tower-of-god/list?title_no=95")
+	is.NoErr(err)
+	is.Equal(episode.Title, "[Season 3] Ep. 550")
+	is.Equal(episode.Number, "550")
+}
+
+func TestGetMangaInfo(t *testing.T) {
+	is := is.New(t)
+	manga := NewWebtoons(nil)
+	server := testListServer()
+	defer server.Close()
+
+	t.Run("No title URL supplied", func(t *testing.T) {
+		info, err := manga.GetMangaInfo("")
+		is.True(info == nil)
+		is.True(err != nil)
+	})
+
+	t.Run("Happy path", func(t *testing.T) {
+		info, err := manga.GetMangaInfo(server.URL + "/en/fantasy/tower-of-god/list?title_no=95")
+		is.NoErr(err)
+		is.Equal(info.Feed, 7)
+		is.Equal(info.Title, "Tower of God")
+		is.Equal(info.Author, "SIU")
+		is.Equal(info.Genres, []string{"Fantasy"})
+		is.Equal(info.Status, "Ongoing")
+		is.Equal(info.Cover, "https://swebtoon-phinf.pstatic.net/20150331_204/tower_og.jpg")
+		is.True(strings.HasPrefix(info.Description, "What do you desire?"))
+		is.Equal(info.Chapters[0].Number, "550")
+		is.Equal(info.Chapters[1].Number, "549")
+	})
+}
+
+func TestGetEpisodes(t *testing.T) {
+	is := is.New(t)
+	manga := NewWebtoons(nil)
+	server := testListServer()
+	defer server.Close()
+
+	t.Run("No title URL supplied", func(t *testing.T) {
+		_, err := manga.GetEpisodes("")
+		is.True(err != nil)
+	})
+
+	t.Run("Every page is loaded", func(t *testing.T) {
+		episodes, err := manga.GetEpisodes(server.URL + "/en/fantasy/tower-of-god/list?title_no=95")
+		is.NoErr(err)
+		is.Equal(len(episodes), 7)
+		is.Equal(episodes[0].Title, "[Season 3] Ep. 550")
+		is.Equal(episodes[6].Title, "[Season 3] Ep. 544")
+	})
+}
+
+func TestSubscribe(t *testing.T) {
+	is := is.New(t)
+	opts := &mtest.Options{}
+	opts.ClientType(mtest.Mock)
+	opts.CollectionName("subscription")
+	opts.DatabaseName("mangagram")
+	opts.ShareClient(true)
+
+	mt := mtest.New(t, opts)
+	defer mt.Close()
+
+	manga := NewWebtoons(&models.DatabaseConfig{
+		Ctx:         context.Background(),
+		MongoClient: mt.Client.Database("mangagram"),
+	})
+	server := testListServer()
+	defer server.Close()
+
+	t.Run("No manga name supplied", func(t *testing.T) {
+		sub := &models.Subscription{}
+		err := manga.Subscribe(sub)
+		is.True(err != nil)
+		is.True(strings.Contains(err.Error(), "no manga supplied"))
+	})
+
+	t.Run("No Chat ID supplied", func(t *testing.T) {
+		sub := &models.Subscription{
+			MangaName: "Tower of God",
+			MangaURL:  server.URL,
+		}
+		err := manga.Subscribe(sub)
+		is.True(err != nil)
+		is.True(strings.Contains(err.Error(), "no Chat supplied"))
+	})
+
+	t.Run("Error inserting subscription", func(t *testing.T) {
+		sub := &models.Subscription{
+			MangaName: "Tower of God",
+			MangaURL:  server.URL,
+			ChatID:    10,
+		}
+
+		mt.AddMockResponses(mtest.CreateWriteErrorsResponse(mtest.WriteError{
+			Code:    11000,
+			Message: "something went wrong",
+		}))
+
+		err := manga.Subscribe(sub)
+		is.True(err != nil)
+	})
+
+	t.Run("Happy path", func(t *testing.T) {
+		sub := &models.Subscription{
+			MangaName: "Tower of God",
+			MangaURL:  server.URL,
+			ChatID:    10,
+		}
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+
+		err := manga.Subscribe(sub)
+		is.NoErr(err)
+		is.Equal(sub.MangaFeed, 7)
+	})
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"log"
+	"strings"
+
+	"github.com/tavomoya/mangagram/actions"
+	"github.com/tavomoya/mangagram/actions/i18n"
+	"github.com/tavomoya/mangagram/models"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// withoutButtons returns the inline keyboard of a message
+// without the buttons for which drop returns true.
+func withoutButtons(m *tb.Message, drop func(btn tb.InlineButton) bool) *tb.ReplyMarkup {
+	kb := [][]tb.InlineButton{}
+	for _, row := range m.ReplyMarkup.InlineKeyboard {
+		keep := []tb.InlineButton{}
+		for _, btn := range row {
+			if !drop(btn) {
+				keep = append(keep, btn)
+			}
+		}
+
+		if len(keep) > 0 {
+			kb = append(kb, keep)
+		}
+	}
+
+	return &tb.ReplyMarkup{InlineKeyboard: kb}
+}
+
+// handleAlerts registers the handlers of the new chapter
+// alert buttons and the /unread command.
+func handleAlerts(bot *tb.Bot, db *models.DatabaseConfig) {
+
+	bot.Handle(&actions.AlertMarkReadBtn, func(c *tb.Callback) {
+		locale := chatLocale(db, c.Message.Chat, c.Sender)
+
+		err := actions.MarkAlertRead(db, c.Message.Chat.ID, c.Data, c.Sender.ID)
+		if err != nil {
+			log.Println("There was an error marking the chapter as read: ", err)
+			bot.Respond(c, &tb.CallbackResponse{Text: i18n.T(locale, "alert.error"), ShowAlert: true})
+			return
+		}
+
+		// Every member of a group keeps their own read state,
+		// so the button is only gone for private chats
+		if c.Message.Chat.Type == tb.ChatPrivate {
+			markup := withoutButtons(c.Message, func(btn tb.InlineButton) bool {
+				return strings.HasPrefix(btn.Data, "\f"+actions.AlertMarkReadBtn.Unique)
+			})
+			bot.EditReplyMarkup(c.Message, markup)
+		}
+
+		bot.Respond(c, &tb.CallbackResponse{Text: i18n.T(locale, "alert.marked_read")})
+	})
+
+	bot.Handle(&actions.AlertUnsubscribeBtn, func(c *tb.Callback) {
+		locale := chatLocale(db, c.Message.Chat, c.Sender)
+
+		if !canRemove(db, c.Message.Chat, c.Sender, c.Data) {
+			bot.Respond(c, &tb.CallbackResponse{Text: i18n.T(locale, "permissions.denied"), ShowAlert: true})
+			return
+		}
+
+		// Removals are confirmed, and can be undone, like from /subscriptions
+		confirmRemove(bot, db, c, locale, c.Data)
+	})
+
+	bot.Handle("/unread", func(m *tb.Message) {
+		locale := chatLocale(db, m.Chat, m.Sender)
+
+		titles, err := actions.GetUnreadTitles(db, m.Sender.ID, m.Chat.ID)
+		if err != nil {
+			log.Println("There was an error getting the unread titles: ", err)
+			bot.Send(m.Chat, i18n.T(locale, "unread.error"))
+			return
+		}
+
+		if len(titles) == 0 {
+			bot.Send(m.Chat, i18n.T(locale, "unread.empty"))
+			return
+		}
+
+		_, err = bot.Send(m.Chat, actions.UnreadMessage(locale, titles), tb.ModeHTML, tb.NoPreview)
+		if err != nil {
+			log.Println("There was an error sending the unread titles: ", err)
+		}
+	})
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"html"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/tavomoya/mangagram/actions"
+	"github.com/tavomoya/mangagram/actions/i18n"
+	"github.com/tavomoya/mangagram/models"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// Buttons of the dialogs that confirm removing a subscription
+// and changing the feed. Their Unique is fixed so a single handler
+// serves the dialogs of every chat. The Data of the remove and undo
+// buttons is the subscription's ID, the one of setfeedConfirmBtn
+// is the code of the feed chosen.
+var (
+	removeConfirmBtn  = tb.InlineButton{Unique: "confirm_remove"}
+	removeUndoBtn     = tb.InlineButton{Unique: "undo_remove"}
+	setfeedConfirmBtn = tb.InlineButton{Unique: "confirm_setfeed"}
+	confirmCancelBtn  = tb.InlineButton{Unique: "confirm_cancel"}
+)
+
+// confirmMarkup returns the keyboard of a dialog: the confirm
+// button, with a text and data, and the cancel button.
+func confirmMarkup(locale string, confirm tb.InlineButton, text, data string) *tb.ReplyMarkup {
+	confirm.Text = text
+	confirm.Data = data
+
+	cancel := confirmCancelBtn
+	cancel.Text = i18n.T(locale, "confirm.cancel")
+
+	return &tb.ReplyMarkup{InlineKeyboard: [][]tb.InlineButton{{confirm, cancel}}}
+}
+
+// confirmRemove sends the dialog that asks
+// to confirm removing a subscription.
+func confirmRemove(bot *tb.Bot, db *models.DatabaseConfig, c *tb.Callback, locale, subscriptionID string) {
+	sub, err := actions.GetSubscription(db, subscriptionID)
+	if err != nil || !sub.DeletedAt.IsZero() {
+		bot.Respond(c, &tb.CallbackResponse{Text: i18n.T(locale, "alert.error"), ShowAlert: true})
+		return
+	}
+
+	markup := confirmMarkup(locale, removeConfirmBtn, i18n.T(locale, "subscriptions.remove"), subscriptionID)
+
+	_, err = bot.Send(c.Message.Chat, i18n.T(locale, "remove.confirm", html.EscapeString(sub.MangaName)), markup, tb.ModeHTML)
+	if err != nil {
+		log.Println("There was an error sending the remove dialog: ", err)
+	}
+
+	bot.Respond(c)
+}
+
+// feedDialog returns the text and keyboard of the
+// dialog that asks to confirm changing the feed.
+func feedDialog(locale string, feedCode int) (string, *tb.ReplyMarkup) {
+	markup := confirmMarkup(locale, setfeedConfirmBtn, i18n.T(locale, "setfeed.confirm_yes"), strconv.Itoa(feedCode))
+	return i18n.T(locale, "setfeed.confirm", actions.FeedName(feedCode)), markup
+}
+
+// confirmSetFeed turns the /setfeed message into
+// the dialog that asks to confirm changing the feed.
+func confirmSetFeed(bot *tb.Bot, c *tb.Callback, locale string, feedCode int) {
+	msg, markup := feedDialog(locale, feedCode)
+
+	_, err := bot.Edit(c.Message, msg, markup, tb.ModeHTML)
+	if err != nil {
+		log.Println("There was an error sending the feed dialog: ", err)
+	}
+
+	bot.Respond(c)
+}
+
+// handleConfirm registers the handlers of the buttons
+// of the remove and feed dialogs, and of undoing removals.
+func handleConfirm(bot *tb.Bot, db *models.DatabaseConfig) {
+
+	bot.Handle(&confirmCancelBtn, func(c *tb.Callback) {
+		err := bot.Delete(c.Message)
+		if err != nil {
+			log.Println("There was an error deleting the dialog: ", err)
+		}
+
+		bot.Respond(c)
+	})
+
+	bot.Handle(&removeConfirmBtn, func(c *tb.Callback) {
+		locale := chatLocale(db, c.Message.Chat, c.Sender)
+
+		sub, err := actions.GetSubscription(db, c.Data)
+		if err != nil {
+			bot.Respond(c, &tb.CallbackResponse{Text: i18n.T(locale, "alert.error"), ShowAlert: true})
+			return
+		}
+
+		if !canRemove(db, c.Message.Chat, c.Sender, c.Data) {
+			bot.Respond(c, &tb.CallbackResponse{Text: i18n.T(locale, "permissions.denied"), ShowAlert: true})
+			return
+		}
+
+		err = actions.RemoveMemberSubscription(db, c.Data, c.Sender.ID)
+		if err != nil {
+			log.Println("There was an error removing subscription: ", err)
+			bot.Respond(c, &tb.CallbackResponse{Text: removeError(locale, err), ShowAlert: true})
+			return
+		}
+
+		undo := removeUndoBtn
+		undo.Text = i18n.T(locale, "remove.undo")
+		undo.Data = c.Data
+
+		msg := i18n.T(locale, "remove.done", html.EscapeString(sub.MangaName), int(actions.UndoWindow.Minutes()))
+		_, err = bot.Edit(c.Message, msg, &tb.ReplyMarkup{InlineKeyboard: [][]tb.InlineButton{{undo}}}, tb.ModeHTML)
+		if err != nil {
+			log.Println("There was an error updating the remove dialog: ", err)
+		}
+
+		bot.Respond(c, &tb.CallbackResponse{Text: i18n.T(locale, "subscriptions.removed")})
+	})
+
+	// expired tells a chat member a removal can't be
+	// undone anymore, and removes the Undo button
+	expired := func(c *tb.Callback, locale string) {
+		bot.Edit(c.Message, i18n.T(locale, "remove.expired"))
+		bot.Respond(c, &tb.CallbackResponse{Text: i18n.T(locale, "remove.expired"), ShowAlert: true})
+	}
+
+	bot.Handle(&removeUndoBtn, func(c *tb.Callback) {
+		locale := chatLocale(db, c.Message.Chat, c.Sender)
+
+		// Subscriptions deleted for good are not found anymore
+		sub, err := actions.GetSubscription(db, c.Data)
+		if err != nil || time.Since(c.Message.LastEdited()) > actions.UndoWindow {
+			expired(c, locale)
+			return
+		}
+
+		if !canRemove(db, c.Message.Chat, c.Sender, c.Data) {
+			bot.Respond(c, &tb.CallbackResponse{Text: i18n.T(locale, "permissions.denied"), ShowAlert: true})
+			return
+		}
+
+		err = actions.RestoreMemberSubscription(db, c.Data, actions.NewFollower(c.Sender))
+		if err == actions.ErrUndoExpired {
+			expired(c, locale)
+			return
+		}
+		if err == actions.ErrNotFollower {
+			bot.Respond(c, &tb.CallbackResponse{Text: i18n.T(locale, "remove.not_yours"), ShowAlert: true})
+			return
+		}
+		if err != nil {
+			log.Println("There was an error restoring subscription: ", err)
+			bot.Respond(c, &tb.CallbackResponse{Text: i18n.T(locale, "alert.error"), ShowAlert: true})
+			return
+		}
+
+		_, err = bot.Edit(c.Message, i18n.T(locale, "remove.restored", html.EscapeString(sub.MangaName)), tb.ModeHTML)
+		if err != nil {
+			log.Println("There was an error updating the remove dialog: ", err)
+		}
+
+		bot.Respond(c)
+	})
+
+	bot.Handle(&setfeedConfirmBtn, func(c *tb.Callback) {
+		locale := chatLocale(db, c.Message.Chat, c.Sender)
+
+		if !canManage(db, c.Message.Chat, c.Sender) {
+			bot.Respond(c, &tb.CallbackResponse{Text: i18n.T(locale, "permissions.denied"), ShowAlert: true})
+			return
+		}
+
+		code, _ := strconv.Atoi(c.Data)
+
+		var feed *models.MangaFeed
+		for i, f := range actions.AvailableFeeds {
+			if f.Code == code {
+				feed = &actions.AvailableFeeds[i]
+				break
+			}
+		}
+
+		if feed == nil {
+			bot.Respond(c, &tb.CallbackResponse{Text: i18n.T(locale, "alert.error"), ShowAlert: true})
+			return
+		}
+
+		err := actions.AddFeedSubscription(db, c.Message.Chat.ID, *feed)
+		if err != nil {
+			log.Println("There was an error adding feed subscription: ", err)
+			bot.Respond(c, &tb.CallbackResponse{Text: i18n.T(locale, "alert.error"), ShowAlert: true})
+			return
+		}
+
+		_, err = bot.Edit(c.Message, i18n.T(locale, "setfeed.changed"))
+		if err != nil {
+			log.Println("There was an error updating the feed dialog: ", err)
+		}
+
+		bot.Respond(c)
+
+		// Searching the new feed for every title takes a while
+		chat := c.Message.Chat
+		go func() {
+			moved, total, err := actions.MigrateChatSubscriptions(db, chat.ID, code)
+			if err != nil {
+				log.Println("There was an error moving subscriptions to the new feed: ", err)
+				return
+			}
+
+			if total > 0 {
+				bot.Send(chat, i18n.T(locale, "setfeed.migrated", moved, total, actions.FeedName(code)))
+			}
+		}()
+	})
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"html"
+	"log"
+
+	"github.com/tavomoya/mangagram/actions"
+	"github.com/tavomoya/mangagram/actions/i18n"
+	"github.com/tavomoya/mangagram/models"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// shareLink returns the link to share a subscription's title in any
+// chat with a deep link to subscribe to it, or an empty string if it
+// can't be shared. Subscriptions not linked to their series yet are
+// linked first.
+func shareLink(bot *tb.Bot, db *models.DatabaseConfig, sub *models.Subscription) string {
+	if bot.Me == nil || sub.MangaFeed == models.FeedRSS {
+		return ""
+	}
+
+	if sub.MangaID.IsZero() && actions.LinkSubscription(db, sub) != nil {
+		return ""
+	}
+
+	link := actions.DeepLink(bot.Me.Username, actions.SubscribePayload(sub.MangaFeed, sub.MangaID))
+
+	return actions.ShareLink(link, sub.MangaName)
+}
+
+// subscribeDeepLink subscribes the chat of a /start message to the
+// manga series of its deep link payload, see actions.SubscribePayload.
+func subscribeDeepLink(bot *tb.Bot, db *models.DatabaseConfig, m *tb.Message, locale string) {
+
+	feedCode, mangaID, err := actions.ParseSubscribePayload(m.Payload)
+	if err != nil {
+		log.Println("Invalid deep link payload: ", m.Payload)
+		bot.Send(m.Chat, i18n.T(locale, "deeplink.invalid"))
+		return
+	}
+
+	feed := actions.NewMangaInterface(feedCode, db)
+	if feed == nil {
+		log.Println("Unknown manga feed in deep link: ", feedCode)
+		bot.Send(m.Chat, i18n.T(locale, "deeplink.invalid"))
+		return
+	}
+
+	manga, err := actions.GetManga(db, mangaID)
+	if err != nil {
+		bot.Send(m.Chat, i18n.T(locale, "deeplink.invalid"))
+		return
+	}
+
+	mangaURL := actions.MangaEntry(manga, feedCode)
+	if mangaURL == "" {
+		log.Println("The manga has no entry in the deep link's feed: ", mangaID.Hex(), feedCode)
+		bot.Send(m.Chat, i18n.T(locale, "deeplink.invalid"))
+		return
+	}
+
+	langs := actions.GetChatSettings(db, m.Chat.ID).Languages
+	actions.SetFeedLanguages(feed, langs)
+
+	sub := &models.Subscription{
+		UserID:    m.Sender.ID,
+		UserName:  m.Sender.FirstName,
+		ChatID:    m.Chat.ID,
+		MangaName: manga.Title,
+		MangaURL:  mangaURL,
+		MangaFeed: feedCode,
+	}
+
+	existing, err := actions.SubscribeManga(db, feed, sub)
+	if err == actions.ErrAlreadySubscribed {
+		bot.Send(m.Chat, i18n.T(locale, "manga.duplicate", existing.MangaName, actions.FeedName(existing.MangaFeed)))
+		return
+	}
+	if err != nil {
+		log.Println("There was an error subscribing from a deep link: ", err)
+		bot.Send(m.Chat, i18n.T(locale, "deeplink.error"))
+		return
+	}
+
+	bot.Send(m.Chat, i18n.T(locale, "deeplink.subscribed", html.EscapeString(manga.Title), actions.FeedName(feedCode)), tb.ModeHTML)
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tavomoya/mangagram/actions"
+	"github.com/tavomoya/mangagram/actions/i18n"
+	"github.com/tavomoya/mangagram/actions/tachiyomi"
+	"github.com/tavomoya/mangagram/models"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// errFileTooBig is returned when a document is too big to be
+// imported, see actions.MaxImportSize and tachiyomi.MaxBackupSize.
+var errFileTooBig = errors.New("the file is too big")
+
+// importInterval is how long a chat waits after an import to start
+// another one, imports search the feeds for every title.
+const importInterval = 10 * time.Minute
+
+// imports holds when the chats that imported subscriptions
+// can import again. Chats importing can't until they're done.
+var imports = struct {
+	sync.Mutex
+	m map[int64]time.Time
+}{m: map[int64]time.Time{}}
+
+// startImport reports whether a chat can import subscriptions now, and
+// if so keeps it from starting another import until finishImport. It
+// returns how long the chat has to wait otherwise.
+func startImport(chatID int64) (time.Duration, bool) {
+	imports.Lock()
+	defer imports.Unlock()
+
+	now := time.Now()
+	for id, t := range imports.m {
+		if now.After(t) {
+			delete(imports.m, id)
+		}
+	}
+
+	if t, ok := imports.m[chatID]; ok {
+		return t.Sub(now), false
+	}
+
+	// Until the import is done
+	imports.m[chatID] = now.Add(24 * time.Hour)
+
+	return 0, true
+}
+
+// finishImport lets a chat import again after importInterval
+// or, if it didn't import anything, right away.
+func finishImport(chatID int64, imported bool) {
+	imports.Lock()
+	defer imports.Unlock()
+
+	if imported {
+		imports.m[chatID] = time.Now().Add(importInterval)
+	} else {
+		delete(imports.m, chatID)
+	}
+}
+
+// importDocument returns the document a /import command is about:
+// the one it's the caption of, or the one it replies to.
+func importDocument(m *tb.Message) *tb.Document {
+	if m.Document != nil {
+		return m.Document
+	}
+
+	if m.ReplyTo != nil {
+		return m.ReplyTo.Document
+	}
+
+	return nil
+}
+
+// downloadDocument returns the contents of a document sent to
+// the bot, if it's not bigger than limit bytes.
+func downloadDocument(bot *tb.Bot, doc *tb.Document, limit int) ([]byte, error) {
+	if doc.FileSize > limit {
+		return nil, errFileTooBig
+	}
+
+	file, err := bot.GetFile(&doc.File)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	data, err := ioutil.ReadAll(io.LimitReader(file, int64(limit)+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) > limit {
+		return nil, errFileTooBig
+	}
+
+	return data, nil
+}
+
+// importSubscriptions subscribes a chat to the subscriptions of a
+// file written by /export, or to the library of a Tachiyomi backup.
+// It takes a while, so it's run in the background, and a chat can
+// only import once every importInterval.
+func importSubscriptions(bot *tb.Bot, db *models.DatabaseConfig, m *tb.Message, doc *tb.Document) {
+	locale := chatLocale(db, m.Chat, m.Sender)
+
+	// Subscriptions are imported on behalf of someone,
+	// and channel posts have no sender
+	if m.Sender == nil {
+		bot.Send(m.Chat, i18n.T(locale, "import.error"))
+		return
+	}
+
+	wait, ok := startImport(m.Chat.ID)
+	if !ok {
+		bot.Send(m.Chat, i18n.T(locale, "import.wait", int(wait.Minutes())+1))
+		return
+	}
+
+	imported := false
+	defer func() { finishImport(m.Chat.ID, imported) }()
+
+	limit := actions.MaxImportSize
+	if tachiyomi.IsBackupFile(doc.FileName) {
+		limit = tachiyomi.MaxBackupSize
+	}
+
+	data, err := downloadDocument(bot, doc, limit)
+	if err == errFileTooBig {
+		bot.Send(m.Chat, i18n.T(locale, "import.too_big"))
+		return
+	}
+	if err != nil {
+		log.Println("There was an error downloading the import file: ", err)
+		bot.Send(m.Chat, i18n.T(locale, "import.error"))
+		return
+	}
+
+	if tachiyomi.IsBackupFile(doc.FileName) {
+		imported = importLibrary(bot, db, m, locale, data)
+		return
+	}
+
+	subs, err := actions.ParseSubscriptionExport(data)
+	if err != nil {
+		bot.Send(m.Chat, i18n.T(locale, "import.invalid", err.Error()))
+		return
+	}
+
+	// Subscribing looks every title up in its feed, which takes a while
+	bot.Send(m.Chat, i18n.T(locale, "import.started", len(subs)))
+	imported = true
+
+	result, err := actions.ImportSubscriptions(db, m.Chat.ID, m.Sender, subs)
+	sendImportResult(bot, m.Chat, locale, result, err)
+}
+
+// importLibrary subscribes a chat to the titles of the library of
+// a Tachiyomi backup. It returns false if the backup can't be read.
+func importLibrary(bot *tb.Bot, db *models.DatabaseConfig, m *tb.Message, locale string, data []byte) bool {
+	library, err := tachiyomi.ParseBackup(bytes.NewReader(data))
+	if err != nil {
+		bot.Send(m.Chat, i18n.T(locale, "import.backup_invalid"))
+		return false
+	}
+
+	if len(library) == 0 {
+		bot.Send(m.Chat, i18n.T(locale, "import.backup_empty"))
+		return false
+	}
+
+	if len(library) > actions.MaxLibraryEntries {
+		bot.Send(m.Chat, i18n.T(locale, "import.backup_limit", actions.MaxLibraryEntries))
+		library = library[:actions.MaxLibraryEntries]
+	}
+
+	// Every title is searched in the feeds, which takes a while
+	bot.Send(m.Chat, i18n.T(locale, "import.backup_started", len(library)))
+
+	result, err := actions.ImportLibrary(db, m.Chat.ID, m.Sender, library)
+	sendImportResult(bot, m.Chat, locale, result, err)
+
+	return true
+}
+
+// sendImportResult tells a chat what
+// happened to the subscriptions it imported.
+func sendImportResult(bot *tb.Bot, chat *tb.Chat, locale string, result *actions.ImportResult, err error) {
+	if err != nil {
+		bot.Send(chat, i18n.T(locale, "import.error"))
+		return
+	}
+
+	_, err = bot.Send(chat, actions.ImportMessage(locale, result), tb.ModeHTML)
+	if err != nil {
+		log.Println("There was an error sending the import result: ", err)
+	}
+}
+
+// handleExport registers the /export and /import command handlers,
+// and the one of documents sent with /import as their caption or,
+// in private chats, of Tachiyomi backups.
+func handleExport(bot *tb.Bot, db *models.DatabaseConfig) {
+
+	bot.Handle("/export", func(m *tb.Message) {
+		locale := chatLocale(db, m.Chat, m.Sender)
+
+		data, err := actions.ExportSubscriptions(db, m.Chat.ID)
+		if err != nil {
+			bot.Send(m.Chat, i18n.T(locale, "export.error"))
+			return
+		}
+
+		doc := &tb.Document{
+			File:     tb.FromReader(bytes.NewReader(data)),
+			FileName: fmt.Sprintf("mangagram-%d.json", m.Chat.ID),
+			MIME:     "application/json",
+			Caption:  i18n.T(locale, "export.caption"),
+		}
+
+		_, err = bot.Send(m.Chat, doc)
+		if err != nil {
+			log.Println("There was an error sending the export: ", err)
+			bot.Send(m.Chat, i18n.T(locale, "export.error"))
+		}
+	})
+
+	bot.Handle("/import", func(m *tb.Message) {
+		doc := importDocument(m)
+		if doc == nil {
+			bot.Send(m.Chat, i18n.T(chatLocale(db, m.Chat, m.Sender), "import.help"))
+			return
+		}
+
+		go importSubscriptions(bot, db, m, doc)
+	})
+
+	// Commands in the caption of a document
+	// don't get to the command handlers
+	bot.Handle(tb.OnDocument, func(m *tb.Message) {
+		backup := m.Chat.Type == tb.ChatPrivate && tachiyomi.IsBackupFile(m.Document.FileName)
+
+		if backup || strings.HasPrefix(m.Caption, "/import") {
+			go importSubscriptions(bot, db, m, m.Document)
+		}
+	})
+}
@@ -7,6 +7,7 @@ require (
 	github.com/grokify/html-strip-tags-go v0.0.0-20190921062105-daaa06bf1aaf
 	github.com/matryer/is v1.4.0
 	go.mongodb.org/mongo-driver v1.5.1
+	golang.org/x/text v0.3.5
 	gopkg.in/tucnak/telebot.v2 v2.0.0-20200120165535-b6c3367fed99
-	gopkg.in/yaml.v2 v2.4.0 // indirect
+	gopkg.in/yaml.v2 v2.4.0
 )
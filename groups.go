@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/tavomoya/mangagram/actions"
+	"github.com/tavomoya/mangagram/actions/i18n"
+	"github.com/tavomoya/mangagram/models"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// followBtn is the button of the /subscriptions list of group chats that
+// makes the member who presses it follow the subscription, or stop
+// following it. Its Data is the subscription's ID.
+var followBtn = tb.InlineButton{Unique: "sub_follow"}
+
+// admins caches the admins of the group chats the bot is in.
+var admins *actions.AdminCache
+
+// canManage reports whether a user can remove the subscriptions of a
+// chat and change its feed, see actions.CanManage. Users whose
+// permissions can't be checked can't, nor can messages without a
+// sender, like channel posts and the ones of anonymous admins.
+func canManage(db *models.DatabaseConfig, chat *tb.Chat, user *tb.User) bool {
+	if user == nil {
+		return false
+	}
+
+	ok, err := actions.CanManage(actions.GetChatSettings(db, chat.ID), admins, chat, user.ID)
+	if err != nil {
+		log.Println("There was an error checking the chat's admins: ", err)
+		return false
+	}
+
+	return ok
+}
+
+// canRemove reports whether a chat member can remove a subscription of
+// the chat. Personal subscriptions are only unfollowed, so the member
+// doesn't need to be able to manage the chat. Button data can be forged,
+// so subscriptions of other chats can't be removed.
+func canRemove(db *models.DatabaseConfig, chat *tb.Chat, user *tb.User, subscriptionID string) bool {
+	sub, err := actions.GetSubscription(db, subscriptionID)
+	if err != nil || sub.ChatID != chat.ID {
+		return false
+	}
+
+	return sub.Personal || canManage(db, chat, user)
+}
+
+// isGroup reports whether a chat is a group chat.
+func isGroup(chat *tb.Chat) bool {
+	return chat.Type == tb.ChatGroup || chat.Type == tb.ChatSuperGroup
+}
+
+// subscriptionTitle returns the text of a subscription's button
+// in the /subscriptions list, with how many members of the group
+// follow it.
+func subscriptionTitle(sub *models.Subscription) string {
+	title := sub.MangaName + " 📖"
+	if sub.Personal {
+		title = "👤 " + title
+	}
+
+	if len(sub.Followers) > 0 {
+		title = fmt.Sprintf("%s (%d)", title, len(sub.Followers))
+	}
+
+	return title
+}
+
+// removeError returns the message shown to a chat member
+// whose subscription couldn't be removed.
+func removeError(locale string, err error) string {
+	if err == actions.ErrNotFollower {
+		return i18n.T(locale, "subscriptions.not_follower")
+	}
+
+	return i18n.T(locale, "alert.error")
+}
+
+// handleGroups registers the handler of the
+// follow button of group chats' subscriptions.
+func handleGroups(bot *tb.Bot, db *models.DatabaseConfig) {
+
+	bot.Handle(&followBtn, func(c *tb.Callback) {
+		locale := chatLocale(db, c.Message.Chat, c.Sender)
+
+		// Button data can be forged, so subscriptions of other chats aren't found
+		sub, err := actions.GetSubscription(db, c.Data)
+		if err != nil || !sub.DeletedAt.IsZero() || sub.ChatID != c.Message.Chat.ID {
+			bot.Respond(c, &tb.CallbackResponse{Text: i18n.T(locale, "alert.error"), ShowAlert: true})
+			return
+		}
+
+		following := actions.IsFollower(sub, c.Sender.ID)
+		if following {
+			err = actions.UnfollowManga(db, sub, c.Sender.ID)
+		} else {
+			err = actions.FollowSubscription(db, sub, actions.NewFollower(c.Sender))
+		}
+
+		if err != nil {
+			log.Println("There was an error following the subscription: ", err)
+			bot.Respond(c, &tb.CallbackResponse{Text: i18n.T(locale, "alert.error"), ShowAlert: true})
+			return
+		}
+
+		msg := i18n.T(locale, "group.followed", sub.MangaName)
+		if following {
+			msg = i18n.T(locale, "group.unfollowed", sub.MangaName)
+		}
+
+		bot.Respond(c, &tb.CallbackResponse{Text: msg, ShowAlert: true})
+	})
+}
@@ -0,0 +1,326 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tavomoya/mangagram/actions"
+	"github.com/tavomoya/mangagram/actions/i18n"
+	"github.com/tavomoya/mangagram/models"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// Telegram's limits for photo captions and text messages
+const (
+	maxCaptionLength = 1024
+	maxMessageLength = 4096
+)
+
+// searchTTL is how long the results of a /manga search are
+// kept to go back to them from the details of a title.
+const searchTTL = time.Hour
+
+// Buttons of the /manga results and detail cards. Their Unique
+// is fixed so a single handler serves the messages of every chat.
+// The Data of detailsBtn and mangaFollowBtn is the row of the title
+// in the results.
+var (
+	detailsBtn     = tb.InlineButton{Unique: "manga_details"}
+	backBtn        = tb.InlineButton{Unique: "manga_back"}
+	mangaFollowBtn = tb.InlineButton{Unique: "manga_follow"}
+)
+
+// savedSearch is a /manga results message
+// replaced by the details of one of its titles.
+type savedSearch struct {
+	Text     string
+	Keyboard [][]tb.InlineButton
+	Saved    time.Time
+}
+
+// searches holds the results messages showing a
+// detail card, by chat and message ID.
+var searches = struct {
+	sync.Mutex
+	m map[string]savedSearch
+}{m: map[string]savedSearch{}}
+
+// searchKey returns the key of a message in searches.
+func searchKey(m *tb.Message) string {
+	return fmt.Sprintf("%d:%d", m.Chat.ID, m.ID)
+}
+
+// saveSearch keeps a results message until its
+// chat goes back to it, or for searchTTL.
+func saveSearch(m *tb.Message) {
+	searches.Lock()
+	defer searches.Unlock()
+
+	for key, s := range searches.m {
+		if time.Since(s.Saved) > searchTTL {
+			delete(searches.m, key)
+		}
+	}
+
+	searches.m[searchKey(m)] = savedSearch{
+		Text:     m.Text,
+		Keyboard: m.ReplyMarkup.InlineKeyboard,
+		Saved:    time.Now(),
+	}
+}
+
+// restoreSearch returns and forgets the results message
+// replaced by m, or false if it's no longer kept.
+func restoreSearch(m *tb.Message) (savedSearch, bool) {
+	searches.Lock()
+	defer searches.Unlock()
+
+	key := searchKey(m)
+	s, ok := searches.m[key]
+	delete(searches.m, key)
+
+	return s, ok && time.Since(s.Saved) <= searchTTL
+}
+
+// resultsKeyboard returns the keyboard of the results shown, or
+// replaced by the details of one of their titles, in m.
+func resultsKeyboard(m *tb.Message) [][]tb.InlineButton {
+	searches.Lock()
+	defer searches.Unlock()
+
+	if s, ok := searches.m[searchKey(m)]; ok && time.Since(s.Saved) <= searchTTL {
+		return s.Keyboard
+	}
+
+	return m.ReplyMarkup.InlineKeyboard
+}
+
+// detailCard returns the info card of a manga title for an edited
+// message. Text messages can't become photos, so the cover is
+// linked first with an invisible text to show up as its preview.
+func detailCard(locale string, info *models.MangaInfo) (string, []interface{}) {
+	if info.Cover == "" {
+		return actions.MangaInfoCard(locale, info, maxMessageLength), []interface{}{tb.ModeHTML, tb.NoPreview}
+	}
+
+	cover := `<a href="` + html.EscapeString(info.Cover) + "\">\u200b</a>"
+	card := actions.MangaInfoCard(locale, info, maxMessageLength-1)
+
+	return cover + card, []interface{}{tb.ModeHTML}
+}
+
+// sendMangaInfo sends the info card of a manga title to a chat, as
+// a photo of its cover when it has one, with a button to read it.
+func sendMangaInfo(bot *tb.Bot, chat *tb.Chat, locale string, info *models.MangaInfo) error {
+	markup := func() *tb.ReplyMarkup {
+		return &tb.ReplyMarkup{InlineKeyboard: [][]tb.InlineButton{{
+			{Text: i18n.T(locale, "info.read"), URL: info.URL},
+		}}}
+	}
+
+	if info.Cover != "" {
+		photo := &tb.Photo{
+			File:    tb.FromURL(info.Cover),
+			Caption: actions.MangaInfoCard(locale, info, maxCaptionLength),
+		}
+
+		_, err := bot.Send(chat, photo, markup(), tb.ModeHTML)
+		if err == nil {
+			return nil
+		}
+
+		// Telegram can't always download the cover,
+		// the card is sent as text in that case
+		log.Println("There was an error sending the manga cover: ", err)
+	}
+
+	msg := actions.MangaInfoCard(locale, info, maxMessageLength)
+	_, err := bot.Send(chat, msg, markup(), tb.ModeHTML, tb.NoPreview)
+
+	return err
+}
+
+// handleInfo registers the /info command, which shows the info
+// of the first title that matches a search, and the Details and
+// Back buttons of the /manga results.
+func handleInfo(bot *tb.Bot, db *models.DatabaseConfig) {
+
+	bot.Handle("/info", func(m *tb.Message) {
+		locale := chatLocale(db, m.Chat, m.Sender)
+
+		name := strings.TrimSpace(m.Payload)
+		if name == "" {
+			bot.Send(m.Chat, i18n.T(locale, "info.no_name"), tb.ModeHTML)
+			return
+		}
+
+		chatSettings := actions.GetChatSettings(db, m.Chat.ID)
+
+		feed := actions.NewMangaInterface(chatSettings.Feed, db)
+		if feed == nil {
+			bot.Send(m.Chat, i18n.T(locale, "chapterlang.feed_error"))
+			return
+		}
+
+		actions.SetFeedLanguages(feed, chatSettings.Languages)
+
+		res := actions.SearchManga(db, feed, name)
+		if res == nil || len(res.Suggestions) == 0 {
+			bot.Send(m.Chat, i18n.T(locale, "manga.not_found"))
+			return
+		}
+
+		found := res.Suggestions[0]
+		mangaURL := fmt.Sprintf(feed.ViewManga(), found.Data)
+
+		info, err := actions.GetMangaInfo(db, chatSettings.Feed, mangaURL)
+		if err != nil {
+			log.Println("There was an error getting the manga info: ", err)
+			bot.Send(m.Chat, i18n.T(locale, "info.error"))
+			return
+		}
+
+		if info.Title == "" {
+			info.Title = found.Value
+		}
+
+		err = sendMangaInfo(bot, m.Chat, locale, info)
+		if err != nil {
+			log.Println("There was an error sending the manga info: ", err)
+		}
+	})
+
+	bot.Handle(&detailsBtn, func(c *tb.Callback) {
+		locale := chatLocale(db, c.Message.Chat, c.Sender)
+
+		kb := c.Message.ReplyMarkup.InlineKeyboard
+		row, err := strconv.Atoi(c.Data)
+		if err != nil || row < 0 || row >= len(kb) || kb[row][0].URL == "" {
+			bot.Respond(c, &tb.CallbackResponse{Text: i18n.T(locale, "info.error"), ShowAlert: true})
+			return
+		}
+
+		title := kb[row][0]
+		feed := actions.GetChatSettings(db, c.Message.Chat.ID).Feed
+
+		info, err := actions.GetMangaInfo(db, feed, title.URL)
+		if err != nil {
+			log.Println("There was an error getting the manga info: ", err)
+			bot.Respond(c, &tb.CallbackResponse{Text: i18n.T(locale, "info.error"), ShowAlert: true})
+			return
+		}
+
+		if info.Title == "" {
+			info.Title = strings.TrimSuffix(title.Text, " 📖")
+		}
+
+		// The title's Subscribe button keeps working in the card
+		actionsRow := []tb.InlineButton{}
+		for _, btn := range kb[row][1:] {
+			if btn.URL == "" && !strings.HasPrefix(btn.Data, "\f"+detailsBtn.Unique) {
+				actionsRow = append(actionsRow, btn)
+			}
+		}
+		actionsRow = append(actionsRow, tb.InlineButton{
+			Unique: backBtn.Unique,
+			Text:   i18n.T(locale, "manga.back"),
+		})
+
+		markup := &tb.ReplyMarkup{InlineKeyboard: [][]tb.InlineButton{
+			{{Text: i18n.T(locale, "info.read"), URL: title.URL}},
+			actionsRow,
+		}}
+
+		saveSearch(c.Message)
+
+		msg, opts := detailCard(locale, info)
+		_, err = bot.Edit(c.Message, msg, append(opts, markup)...)
+		if err != nil {
+			log.Println("There was an error showing the manga details: ", err)
+			restoreSearch(c.Message)
+			bot.Respond(c, &tb.CallbackResponse{Text: i18n.T(locale, "info.error"), ShowAlert: true})
+			return
+		}
+
+		bot.Respond(c, &tb.CallbackResponse{})
+	})
+
+	bot.Handle(&mangaFollowBtn, func(c *tb.Callback) {
+		settings := actions.GetChatSettings(db, c.Message.Chat.ID)
+		locale := chatLocale(db, c.Message.Chat, c.Sender)
+
+		// The button is kept in the details card, where
+		// its row is the one of the replaced results
+		kb := resultsKeyboard(c.Message)
+		row, err := strconv.Atoi(c.Data)
+		if err != nil || row < 0 || row >= len(kb) || kb[row][0].URL == "" {
+			bot.Respond(c, &tb.CallbackResponse{Text: i18n.T(locale, "alert.error"), ShowAlert: true})
+			return
+		}
+
+		feed := actions.NewMangaInterface(settings.Feed, db)
+		if feed == nil {
+			bot.Respond(c, &tb.CallbackResponse{Text: i18n.T(locale, "alert.error"), ShowAlert: true})
+			return
+		}
+
+		actions.SetFeedLanguages(feed, settings.Languages)
+
+		title := kb[row][0]
+		name := strings.TrimSuffix(title.Text, " 📖")
+		if i := strings.LastIndex(name, " ["); len(settings.Languages) > 1 && i > 0 && strings.HasSuffix(name, "]") {
+			name = name[:i]
+		}
+
+		sub := &models.Subscription{
+			UserID:    c.Sender.ID,
+			UserName:  c.Sender.FirstName,
+			ChatID:    c.Message.Chat.ID,
+			MangaName: name,
+			MangaURL:  title.URL,
+			MangaFeed: settings.Feed,
+		}
+
+		_, err = actions.FollowManga(db, feed, sub, actions.NewFollower(c.Sender))
+		if err == actions.ErrAlreadySubscribed {
+			bot.Respond(c, &tb.CallbackResponse{
+				Text:      i18n.T(locale, "group.already_following", name),
+				ShowAlert: true,
+			})
+			return
+		}
+		if err != nil {
+			log.Println("There was an error following the manga: ", err)
+			bot.Respond(c, &tb.CallbackResponse{Text: i18n.T(locale, "alert.error"), ShowAlert: true})
+			return
+		}
+
+		bot.Respond(c, &tb.CallbackResponse{
+			Text:      i18n.T(locale, "group.followed", name),
+			ShowAlert: true,
+		})
+	})
+
+	bot.Handle(&backBtn, func(c *tb.Callback) {
+		locale := chatLocale(db, c.Message.Chat, c.Sender)
+
+		search, ok := restoreSearch(c.Message)
+		if !ok {
+			bot.Respond(c, &tb.CallbackResponse{Text: i18n.T(locale, "manga.expired"), ShowAlert: true})
+			return
+		}
+
+		_, err := bot.Edit(c.Message, search.Text, &tb.ReplyMarkup{InlineKeyboard: search.Keyboard})
+		if err != nil {
+			log.Println("There was an error going back to the search results: ", err)
+		}
+
+		bot.Respond(c, &tb.CallbackResponse{})
+	})
+}
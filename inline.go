@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/tavomoya/mangagram/actions"
+	"github.com/tavomoya/mangagram/actions/i18n"
+	"github.com/tavomoya/mangagram/models"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// maxInlineResults is the maximum number of
+// titles answered to an inline query.
+const maxInlineResults = 10
+
+// minInlineQuery is the length a query needs to be searched,
+// inline queries are sent on every key the user types.
+const minInlineQuery = 3
+
+// inlineCacheTime is how long, in seconds, Telegram
+// keeps the answer to an inline query.
+const inlineCacheTime = 300
+
+// inlineResult returns the result of an inline query for a manga title,
+// a card with its info and buttons to read it and subscribe in the bot.
+func inlineResult(bot *tb.Bot, db *models.DatabaseConfig, locale string, feedCode int, title models.MangaSuggestions, mangaURL string) tb.Result {
+
+	info := actions.CachedMangaInfo(db, mangaURL)
+	if info == nil {
+		info = &models.MangaInfo{URL: mangaURL}
+	}
+
+	if info.Title == "" {
+		info.Title = title.Value
+	}
+
+	msg, _ := detailCard(locale, info)
+
+	result := &tb.ArticleResult{
+		Title:       title.Value,
+		Description: actions.FeedName(feedCode),
+		URL:         mangaURL,
+		HideURL:     true,
+		ThumbURL:    info.Cover,
+	}
+
+	if title.Language != "" {
+		result.Description = fmt.Sprintf("%s [%s]", result.Description, title.Language)
+	}
+
+	result.SetContent(&tb.InputTextMessageContent{
+		Text:           msg,
+		ParseMode:      string(tb.ModeHTML),
+		DisablePreview: info.Cover == "",
+	})
+
+	kb := [][]tb.InlineButton{{{Text: i18n.T(locale, "info.read"), URL: mangaURL}}}
+
+	// Deep links refer to the title's series, the bot's
+	// username is only known once it's running
+	manga, err := actions.ResolveManga(db, feedCode, mangaURL, title.Value, nil)
+	if err == nil && bot.Me != nil {
+		link := actions.DeepLink(bot.Me.Username, actions.SubscribePayload(feedCode, manga.ID))
+		kb = append(kb, []tb.InlineButton{{Text: i18n.T(locale, "inline.subscribe"), URL: link}})
+	}
+
+	result.SetReplyMarkup(kb)
+
+	return result
+}
+
+// handleInline registers the handler of inline queries
+// (@bot {title}), which searches the feed of the user's
+// private chat with the bot and answers with title cards
+// that can be shared in any chat.
+func handleInline(bot *tb.Bot, db *models.DatabaseConfig) {
+
+	bot.Handle(tb.OnQuery, func(q *tb.Query) {
+
+		// Users talk to the bot in their private chat,
+		// which has the same ID as the user
+		chat := &tb.Chat{ID: int64(q.From.ID)}
+		locale := chatLocale(db, chat, &q.From)
+
+		query := strings.TrimSpace(q.Text)
+		response := &tb.QueryResponse{
+			Results:    tb.Results{},
+			CacheTime:  inlineCacheTime,
+			IsPersonal: true,
+		}
+
+		if len([]rune(query)) < minInlineQuery {
+			response.SwitchPMText = i18n.T(locale, "inline.help")
+			response.SwitchPMParameter = "inline"
+			if err := bot.Answer(q, response); err != nil {
+				log.Println("There was an error answering the inline query: ", err)
+			}
+			return
+		}
+
+		chatSettings := actions.GetChatSettings(db, chat.ID)
+
+		feed := actions.NewMangaInterface(chatSettings.Feed, db)
+		if feed == nil {
+			log.Println("Unknown manga feed: ", chatSettings.Feed)
+			return
+		}
+
+		actions.SetFeedLanguages(feed, chatSettings.Languages)
+
+		res := actions.SearchManga(db, feed, query)
+		if res == nil || len(res.Suggestions) == 0 {
+			response.SwitchPMText = i18n.T(locale, "inline.not_found")
+			response.SwitchPMParameter = "inline"
+		} else {
+			for i, s := range res.Suggestions {
+				if i >= maxInlineResults {
+					break
+				}
+
+				mangaURL := fmt.Sprintf(feed.ViewManga(), s.Data)
+				result := inlineResult(bot, db, locale, chatSettings.Feed, s, mangaURL)
+				result.SetResultID(strconv.Itoa(i))
+
+				response.Results = append(response.Results, result)
+			}
+		}
+
+		err := bot.Answer(q, response)
+		if err != nil {
+			log.Println("There was an error answering the inline query: ", err)
+		}
+	})
+}
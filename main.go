@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"html"
 	"log"
 	"os"
 	"strconv"
@@ -10,6 +11,8 @@ import (
 	"time"
 
 	"github.com/tavomoya/mangagram/actions"
+	"github.com/tavomoya/mangagram/actions/i18n"
+	"github.com/tavomoya/mangagram/actions/selector"
 	"github.com/tavomoya/mangagram/models"
 
 	"go.mongodb.org/mongo-driver/mongo"
@@ -30,6 +33,44 @@ func getMongoClient(conn string) (*mongo.Database, error) {
 	return db, nil
 }
 
+// chatLocale returns the locale used for messages sent to a chat: the one
+// chosen with /language or, if there's none, the sender's Telegram language.
+func chatLocale(db *models.DatabaseConfig, chat *tb.Chat, user *tb.User) string {
+	if locale := actions.GetChatLocale(db, chat.ID); locale != "" {
+		return locale
+	}
+
+	if user == nil {
+		return i18n.DefaultLocale
+	}
+
+	return i18n.Locale(user.LanguageCode)
+}
+
+// updateChat returns the chat an update comes
+// from, or nil if it doesn't come from a chat.
+func updateChat(u *tb.Update) *tb.Chat {
+	switch {
+	case u.Message != nil:
+		return u.Message.Chat
+	case u.Callback != nil && u.Callback.Message != nil:
+		return u.Callback.Message.Chat
+	}
+
+	return nil
+}
+
+// feedList returns the list of available
+// feeds used in the start and help messages.
+func feedList() string {
+	feeds := make([]string, 0, len(actions.AvailableFeeds))
+	for _, f := range actions.AvailableFeeds {
+		feeds = append(feeds, fmt.Sprintf("- %s (%s)", f.Name, f.URL))
+	}
+
+	return strings.Join(feeds, "\n")
+}
+
 func main() {
 	log.Println("Started Manga Gram bot")
 
@@ -59,14 +100,43 @@ func main() {
 		MongoClient:      db,
 	}
 
+	if path := os.Getenv("SELECTOR_FEEDS"); path != "" {
+		feeds, err := selector.LoadConfig(path)
+		if err != nil {
+			log.Fatal("There was an error loading selector feeds: ", err)
+		}
+
+		err = actions.RegisterSelectorFeeds(feeds)
+		if err != nil {
+			log.Fatal("There was an error registering selector feeds: ", err)
+		}
+	}
+
 	webhook := &tb.Webhook{
 		Listen:   listen,
 		Endpoint: &tb.WebhookEndpoint{PublicURL: publicURL},
 	}
 
+	// Chats that talk to the bot again get
+	// their paused subscriptions back
+	poller := tb.NewMiddlewarePoller(webhook, func(u *tb.Update) bool {
+		if chat := updateChat(u); chat != nil {
+			ok, err := actions.ReactivateChat(dbConfig, chat.ID)
+			if err != nil {
+				log.Println("There was an error reactivating chat: ", err)
+			}
+
+			if ok {
+				log.Println("Reactivated subscriptions of chat: ", chat.ID)
+			}
+		}
+
+		return true
+	})
+
 	settings := tb.Settings{
 		Token:  token,
-		Poller: webhook,
+		Poller: poller,
 	}
 
 	bot, err := tb.NewBot(settings)
@@ -74,41 +144,33 @@ func main() {
 		log.Fatal("there was an error creating the bot: ", err)
 	}
 
+	admins = actions.NewAdminCache(bot.AdminsOf, actions.AdminCacheTTL)
+
 	jobs := &models.Job{
 		DB: dbConfig,
 	}
 
+	err = actions.EnsureMangaIndexes(dbConfig)
+	if err != nil {
+		log.Println("There was an error creating the manga indexes: ", err)
+	}
+
 	// Run Jobs
-	go actions.GetMangaUpdates(jobs, bot)
+	go actions.GetMangaUpdates(jobs)
+	go actions.DeliverQueuedAlerts(jobs, bot)
 
 	// Available commands:
 
 	bot.Handle("/start", func(m *tb.Message) {
+		locale := chatLocale(dbConfig, m.Chat, m.Sender)
 
-		msg := `
-		Hi! This is MangaGram, a Telegram bot for alerts on your favorite manga titles.
-
-		<b>Available Commmands:</b>
-		/manga {title} - Get a list of mangas that match the title
-		/subscriptions - Get a list of the chat's current manga subscriptions
-		/setfeed - Change manga feed used for manga searches (defaults to Manga Reader)
-		/help - Info about available commands and mangafeeds
-		
-		<b>Manga Feeds</b>
-		Currently MangaGram supplies manga results from the following pages:
-
-		- Manga Reader (http://manga-reader.fun)
-		- Manganelo (https://manganelo.com)
-		- Mangaeden (https://mangaeden.com)
-		- Kissmanga (https://kissmanga.com)
-		- Mangadex (https://mangadex.org)
-
-		You can set your favorite one using the /setfeed command.
-		
-		If you need help use the /help command.
+		// Deep links (t.me/bot?start=payload) carry a payload
+		if actions.IsSubscribePayload(m.Payload) {
+			subscribeDeepLink(bot, dbConfig, m, locale)
+			return
+		}
 
-		MangaGram v1.1.3 Made with ❤️ by @tavomoya.
-		`
+		msg := i18n.T(locale, "start", i18n.T(locale, "help", feedList()))
 
 		_, err := bot.Send(m.Chat, msg, tb.ModeHTML, tb.NoPreview)
 		if err != nil {
@@ -120,46 +182,62 @@ func main() {
 	bot.Handle("/manga", func(m *tb.Message) {
 
 		name := m.Payload
+		locale := chatLocale(dbConfig, m.Chat, m.Sender)
 
 		if name == "" {
-			bot.Send(m.Chat, "<b>No manga name supplied</b>", tb.ModeHTML)
+			bot.Send(m.Chat, i18n.T(locale, "manga.no_name"), tb.ModeHTML)
 		}
 
-		feedSrc := actions.GetChatMangaFeed(dbConfig, m.Chat.ID)
-		if feedSrc == 0 {
-			// Do something I guess?
+		chatSettings := actions.GetChatSettings(dbConfig, m.Chat.ID)
+
+		feed := actions.NewMangaInterface(chatSettings.Feed, dbConfig)
+		if feed == nil {
+			bot.Send(m.Chat, i18n.T(locale, "chapterlang.feed_error"))
+			return
 		}
 
-		feed := actions.NewMangaInterface(feedSrc, dbConfig)
+		langs := chatSettings.Languages
+		actions.SetFeedLanguages(feed, langs)
 
-		res := feed.QueryManga(name)
+		res := actions.SearchManga(dbConfig, feed, name)
 		if res == nil || len(res.Suggestions) == 0 {
-			bot.Send(m.Chat, "No Manga found with your criteria")
+			bot.Send(m.Chat, i18n.T(locale, "manga.not_found"))
 			return
 		}
 
-		msg := "These are the manga I found:\n"
+		msg := i18n.T(locale, "manga.found")
 
 		inlineKb := [][]tb.InlineButton{}
 
 		for i, item := range res.Suggestions {
+			title := item.Value
+			if len(langs) > 1 && item.Language != "" {
+				title = fmt.Sprintf("%s [%s]", item.Value, item.Language)
+			}
+
+			manganame := item.Value
+
 			inlineBtn := []tb.InlineButton{
 				{
-					Text:   item.Value + " 📖",
+					Text:   title + " 📖",
 					Unique: item.Data,
 					URL:    fmt.Sprintf(feed.ViewManga(), item.Data),
 				},
 				{
-					Text:   "Subscribe" + " 🔔",
+					Text:   i18n.T(locale, "manga.details"),
+					Unique: detailsBtn.Unique,
+					Data:   strconv.Itoa(i),
+				},
+				{
+					Text:   i18n.T(locale, "manga.subscribe"),
 					Unique: strconv.Itoa(i),
 				},
 			}
 
-			bot.Handle(&inlineBtn[1], func(btnCb *tb.Callback) {
-				fmt.Println("Subscribing user: ", btnCb.Sender.FirstName, inlineBtn[1].Unique, inlineBtn[0].Text, m.Chat.ID)
+			bot.Handle(&inlineBtn[2], func(btnCb *tb.Callback) {
+				fmt.Println("Subscribing user: ", btnCb.Sender.FirstName, inlineBtn[2].Unique, inlineBtn[0].Text, m.Chat.ID)
 
 				// Call the subscribe method of the feed
-				manganame := strings.Replace(inlineBtn[0].Text, " 📖", "", 1)
 				mangaurl := fmt.Sprintf(feed.ViewManga(), inlineBtn[0].Unique)
 
 				sub := &models.Subscription{
@@ -168,19 +246,37 @@ func main() {
 					ChatID:    m.Chat.ID,
 					MangaName: manganame,
 					MangaURL:  mangaurl,
+					MangaFeed: chatSettings.Feed,
 				}
 
-				err = feed.Subscribe(sub)
+				// The same series might be followed from another feed
+				existing, err := actions.SubscribeManga(dbConfig, feed, sub)
+				if err == actions.ErrAlreadySubscribed {
+					bot.Respond(btnCb, &tb.CallbackResponse{
+						Text:      i18n.T(locale, "manga.duplicate", existing.MangaName, actions.FeedName(existing.MangaFeed)),
+						ShowAlert: true,
+					})
+					return
+				}
 				if err != nil {
 					log.Fatal("There was an error subscribing user: ", err)
 				}
 
 				bot.Respond(btnCb, &tb.CallbackResponse{
-					Text:      "Succesfully subscribed",
+					Text:      i18n.T(locale, "manga.subscribed"),
 					ShowAlert: true,
 				})
 			})
 
+			// Members of a group can follow a title themselves
+			if isGroup(m.Chat) {
+				inlineBtn = append(inlineBtn, tb.InlineButton{
+					Text:   i18n.T(locale, "manga.follow"),
+					Unique: mangaFollowBtn.Unique,
+					Data:   strconv.Itoa(i),
+				})
+			}
+
 			inlineKb = append(inlineKb, inlineBtn)
 		}
 
@@ -193,7 +289,97 @@ func main() {
 		}
 	})
 
+	bot.Handle("/follow_rss", func(m *tb.Message) {
+
+		feedURL := strings.TrimSpace(m.Payload)
+		locale := chatLocale(dbConfig, m.Chat, m.Sender)
+
+		if feedURL == "" {
+			bot.Send(m.Chat, i18n.T(locale, "rss.no_url"), tb.ModeHTML)
+			return
+		}
+
+		feed := actions.NewMangaInterface(models.FeedRSS, dbConfig)
+
+		res := feed.QueryManga(feedURL)
+		if res == nil || len(res.Suggestions) == 0 {
+			bot.Send(m.Chat, i18n.T(locale, "rss.not_found"))
+			return
+		}
+
+		sub := &models.Subscription{
+			UserID:    m.Sender.ID,
+			UserName:  m.Sender.FirstName,
+			ChatID:    m.Chat.ID,
+			MangaName: res.Suggestions[0].Value,
+			MangaURL:  res.Suggestions[0].Data,
+		}
+
+		// The feed might have been removed a moment ago
+		err := actions.ClearRemovedSubscription(dbConfig, sub.ChatID, sub.MangaURL)
+		if err == nil {
+			err = feed.Subscribe(sub)
+		}
+		if err != nil {
+			log.Println("There was an error subscribing to feed: ", err)
+			bot.Send(m.Chat, i18n.T(locale, "rss.error"))
+			return
+		}
+
+		bot.Send(m.Chat, i18n.T(locale, "rss.subscribed", html.EscapeString(sub.MangaName)), tb.ModeHTML)
+	})
+
+	bot.Handle("/chapterlang", func(m *tb.Message) {
+		locale := chatLocale(dbConfig, m.Chat, m.Sender)
+
+		chatSettings := actions.GetChatSettings(dbConfig, m.Chat.ID)
+
+		feed := actions.NewMangaInterface(chatSettings.Feed, dbConfig)
+		if feed == nil {
+			bot.Send(m.Chat, i18n.T(locale, "chapterlang.feed_error"))
+			return
+		}
+
+		supported := actions.FeedLanguages(feed)
+
+		if m.Payload == "" {
+			msg := i18n.T(
+				locale,
+				"chapterlang.current",
+				strings.Join(chatSettings.Languages, ", "),
+				strings.Join(supported, ", "),
+			)
+			bot.Send(m.Chat, msg, tb.ModeHTML)
+			return
+		}
+
+		langs := []string{}
+		for _, l := range strings.Fields(strings.ToLower(m.Payload)) {
+			for _, s := range supported {
+				if l == s {
+					langs = append(langs, l)
+					break
+				}
+			}
+		}
+
+		if len(langs) == 0 {
+			bot.Send(m.Chat, i18n.T(locale, "chapterlang.unsupported", strings.Join(supported, ", ")))
+			return
+		}
+
+		err := actions.SetChatLanguages(dbConfig, m.Chat.ID, langs)
+		if err != nil {
+			log.Println("There was an error setting chat languages: ", err)
+			bot.Send(m.Chat, i18n.T(locale, "chapterlang.error"))
+			return
+		}
+
+		bot.Send(m.Chat, i18n.T(locale, "chapterlang.saved", strings.Join(langs, ", ")))
+	})
+
 	bot.Handle("/subscriptions", func(m *tb.Message) {
+		locale := chatLocale(dbConfig, m.Chat, m.Sender)
 
 		// Get Chat Subscriptions
 		subs, err := actions.GetChatSubscriptions(dbConfig, m.Chat.ID)
@@ -202,7 +388,7 @@ func main() {
 		}
 
 		if subs == nil || len(subs) == 0 {
-			bot.Send(m.Chat, "<b>You're not subscribed to any mangas yet.</b>", tb.ModeHTML)
+			bot.Send(m.Chat, i18n.T(locale, "subscriptions.empty"), tb.ModeHTML)
 			return
 		}
 
@@ -212,32 +398,46 @@ func main() {
 
 			btn := []tb.InlineButton{
 				{
-					Text:   s.MangaName + " 📖",
+					Text:   subscriptionTitle(s),
 					Unique: s.ID.String(),
 					URL:    s.MangaURL,
 				},
 				{
-					Text:   "Remove ❌",
+					Text:   i18n.T(locale, "subscriptions.remove"),
 					Unique: s.ID.Hex(),
 				},
 			}
 
+			if link := shareLink(bot, dbConfig, s); link != "" {
+				btn = append(btn, tb.InlineButton{
+					Text: i18n.T(locale, "subscriptions.share"),
+					URL:  link,
+				})
+			}
+
+			if isGroup(m.Chat) {
+				follow := followBtn
+				follow.Text = i18n.T(locale, "subscriptions.follow")
+				follow.Data = s.ID.Hex()
+				btn = append(btn, follow)
+			}
+
 			bot.Handle(&btn[1], func(btnCb *tb.Callback) {
-				err = actions.RemoveMangaSubscription(dbConfig, btn[1].Unique)
-				if err != nil {
-					log.Fatal("There was an error removing subscription: ", err)
+				if !canRemove(dbConfig, m.Chat, btnCb.Sender, btn[1].Unique) {
+					bot.Respond(btnCb, &tb.CallbackResponse{
+						Text:      i18n.T(locale, "permissions.denied"),
+						ShowAlert: true,
+					})
+					return
 				}
 
-				bot.Respond(btnCb, &tb.CallbackResponse{
-					Text:      "Subscription removed",
-					ShowAlert: true,
-				})
+				confirmRemove(bot, dbConfig, btnCb, locale, btn[1].Unique)
 			})
 
 			btns = append(btns, btn)
 		}
 
-		_, err = bot.Send(m.Chat, "Current Subscriptions:\n", &tb.ReplyMarkup{
+		_, err = bot.Send(m.Chat, i18n.T(locale, "subscriptions.list"), &tb.ReplyMarkup{
 			InlineKeyboard: btns,
 		})
 		if err != nil {
@@ -247,7 +447,13 @@ func main() {
 
 	bot.Handle("/setfeed", func(m *tb.Message) {
 
-		message := "Select feed:\n\n<b>Keep in mind that selecting a different feed than the one you have will remove any current manga subscriptions</b>"
+		locale := chatLocale(dbConfig, m.Chat, m.Sender)
+		message := i18n.T(locale, "setfeed.select")
+
+		if !canManage(dbConfig, m.Chat, m.Sender) {
+			bot.Send(m.Chat, i18n.T(locale, "permissions.denied"))
+			return
+		}
 
 		btns := [][]tb.InlineButton{}
 		for _, feed := range actions.AvailableFeeds {
@@ -265,20 +471,16 @@ func main() {
 			}
 
 			bot.Handle(&btn[0], func(btnCb *tb.Callback) {
-				c, _ := strconv.Atoi(btn[0].Unique)
-				f := models.MangaFeed{
-					Code: c,
-					URL:  btn[1].Unique,
-				}
-				err = actions.AddFeedSubscription(dbConfig, m.Chat.ID, f)
-				if err != nil {
-					log.Fatal("There was an error adding feed subscription: ", err)
+				if !canManage(dbConfig, btnCb.Message.Chat, btnCb.Sender) {
+					bot.Respond(btnCb, &tb.CallbackResponse{
+						Text:      i18n.T(locale, "permissions.denied"),
+						ShowAlert: true,
+					})
+					return
 				}
 
-				bot.Respond(btnCb, &tb.CallbackResponse{
-					Text:      "Feed changed",
-					ShowAlert: true,
-				})
+				c, _ := strconv.Atoi(btn[0].Unique)
+				confirmSetFeed(bot, btnCb, locale, c)
 			})
 
 			btns = append(btns, btn)
@@ -293,22 +495,9 @@ func main() {
 	})
 
 	bot.Handle("/help", func(m *tb.Message) {
-		msg := `
-		<b>Available Commmands:</b>
-		/manga {title} - Get a list of mangas that match the title
-		/subscriptions - Get a list of the chat's current manga subscriptions
-		/setfeed - Change manga feed used for manga searches (defaults to Manga Reader)
-		/help - Info about available commands and mangafeeds
-		
-		<b>Manga Feeds</b>
-		- Manga Reader (http://manga-reader.fun)
-		- Manganelo (https://manganelo.com)
-		- Mangaeden (https://mangaeden.com)
-		- Kissmanga (https://kissmanga.com)
-		- Mangadex (https://mangadex.org)
-
-		You can set your favorite one using the /setfeed command.
-		`
+		locale := chatLocale(dbConfig, m.Chat, m.Sender)
+		msg := i18n.T(locale, "help", feedList())
+
 		_, err := bot.Send(m.Chat, msg, tb.ModeHTML, tb.NoPreview)
 		if err != nil {
 			log.Println("There was an error sending start msg: ", err)
@@ -316,6 +505,65 @@ func main() {
 		}
 	})
 
+	bot.Handle("/language", func(m *tb.Message) {
+		locale := chatLocale(dbConfig, m.Chat, m.Sender)
+
+		if code := strings.TrimSpace(m.Payload); code != "" && i18n.Locale(code) == strings.ToLower(code) {
+			err := actions.SetChatLocale(dbConfig, m.Chat.ID, i18n.Locale(code))
+			if err != nil {
+				log.Println("There was an error changing the chat locale: ", err)
+				bot.Send(m.Chat, i18n.T(locale, "language.error"))
+				return
+			}
+
+			bot.Send(m.Chat, i18n.T(code, "language.changed"))
+			return
+		}
+
+		btns := [][]tb.InlineButton{}
+		for _, l := range i18n.Locales() {
+			btn := languageBtn
+			btn.Text = i18n.T(l, "language.name")
+			btn.Data = l
+
+			btns = append(btns, []tb.InlineButton{btn})
+		}
+
+		_, err := bot.Send(m.Chat, i18n.T(locale, "language.select"), &tb.ReplyMarkup{
+			InlineKeyboard: btns,
+		})
+		if err != nil {
+			log.Println("Unable to respond: ", err)
+		}
+	})
+
+	bot.Handle(&languageBtn, func(btnCb *tb.Callback) {
+		newLocale := i18n.Locale(btnCb.Data)
+
+		err := actions.SetChatLocale(dbConfig, btnCb.Message.Chat.ID, newLocale)
+		if err != nil {
+			log.Println("There was an error changing the chat locale: ", err)
+			bot.Respond(btnCb, &tb.CallbackResponse{
+				Text:      i18n.T(chatLocale(dbConfig, btnCb.Message.Chat, btnCb.Sender), "language.error"),
+				ShowAlert: true,
+			})
+			return
+		}
+
+		bot.Respond(btnCb, &tb.CallbackResponse{
+			Text:      i18n.T(newLocale, "language.changed"),
+			ShowAlert: true,
+		})
+	})
+
+	handleSettings(bot, dbConfig)
+	handleAlerts(bot, dbConfig)
+	handleInfo(bot, dbConfig)
+	handleInline(bot, dbConfig)
+	handleGroups(bot, dbConfig)
+	handleConfirm(bot, dbConfig)
+	handleExport(bot, dbConfig)
+
 	bot.Start()
 
 }
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// QueuedAlert is a struct used to define a new
+// chapter alert in the outbox. Alerts are kept
+// until they are delivered, which might not be
+// right away, like the ones found during a chat's
+// quiet hours or the ones waiting for a digest.
+type QueuedAlert struct {
+	// Internal ID assigned by MongoDB
+	ID primitive.ObjectID `bson:"_id"`
+
+	// ID of the chat the alert is for
+	ChatID int64
+
+	// ID of the subscription the alert comes from
+	SubscriptionID primitive.ObjectID
+
+	// Name of the title with a new chapter
+	MangaName string
+
+	// URL to the title
+	MangaURL string
+
+	// Feed the title belongs to
+	MangaFeed int
+
+	// URL to the title's cover image, might be empty
+	CoverURL string
+
+	// URL of the new chapter
+	ChapterURL string
+
+	// Number of the new chapter, might be empty
+	ChapterNumber string
+
+	// Title of the new chapter, might be empty
+	ChapterTitle string
+
+	// Members of the group chat to mention in the alert
+	Followers []Follower
+
+	// Time the alert must be delivered at
+	DeliverAt time.Time
+
+	// Whether the alert is part of a digest
+	Digest bool
+
+	// Delivery status: pending, delivered or failed
+	Status string
+
+	// Number of failed delivery attempts
+	Attempts int
+
+	// Error of the last failed delivery attempt
+	LastError string
+
+	// Time the alert was delivered at
+	DeliveredAt time.Time
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Manga is a struct used to define a manga series
+// regardless of the feeds it's published in. The
+// entries of the series in each feed link to it, so
+// subscriptions to the same series can be told apart
+// from different ones. It's saved in the 'manga'
+// collection.
+type Manga struct {
+	// Internal ID assigned by MongoDB
+	ID primitive.ObjectID `bson:"_id"`
+
+	// Title of the series, as first seen
+	Title string
+
+	// Other titles the series is known by
+	AltTitles []string
+
+	// Normalized title and alt titles,
+	// used to match entries to the series
+	Titles []string
+
+	// Entries of the series in each feed
+	ExternalIDs []MangaExternalID
+
+	// Date the series was first seen
+	CreatedAt time.Time
+}
+
+// MangaExternalID is a struct used to define
+// the entry of a manga series in a feed.
+type MangaExternalID struct {
+	// Code of the feed
+	Feed int
+
+	// URL to the title in the feed
+	URL string
+}
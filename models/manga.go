@@ -10,6 +10,10 @@ type MangaSuggestions struct {
 	// Title of the manga used as a
 	// message to the user
 	Value string `json:"value"`
+
+	// Language code of the chapters
+	// available for this title
+	Language string `json:"language"`
 }
 
 // ApiQuerySuggestions is a struct used to manage a list
@@ -53,6 +57,18 @@ type MangaedenApiResponse struct {
 	Value string `json:"value"`
 }
 
+// Codes of the built-in manga feeds, see actions.NewMangaInterface.
+// Selector feeds use codes from 100 up.
+const (
+	FeedMangaReader = 1
+	FeedManganelo   = 2
+	FeedMangaEden   = 3
+	FeedKissmanga   = 4
+	FeedMangadex    = 5
+	FeedRSS         = 6
+	FeedWebtoons    = 7
+)
+
 // MangaFeed is a struct used to
 // define feed's information internally.
 type MangaFeed struct {
@@ -81,3 +97,143 @@ type MangareaderApiResponse struct {
 	// Path to the manga URL
 	NameUnsigned string `json:"nameunsigned"`
 }
+
+// MangadexMangaListResponse refers to the type of response
+// that gets returned by the Mangadex API when searching
+// for manga titles.
+type MangadexMangaListResponse struct {
+	// Status of the request, "ok" or "error"
+	Result string `json:"result"`
+
+	// List of mangas that matched the search
+	Data []MangadexManga `json:"data"`
+}
+
+// MangadexManga refers to a single manga entity
+// returned by the Mangadex API.
+type MangadexManga struct {
+	// Mangadex's internal UUID for the manga
+	ID string `json:"id"`
+
+	// Manga's attributes
+	Attributes struct {
+		// Title of the manga indexed by language code
+		Title map[string]string `json:"title"`
+
+		// Alternative titles, each indexed by language code
+		AltTitles []map[string]string `json:"altTitles"`
+
+		// Languages the manga has chapters translated to
+		AvailableTranslatedLanguages []string `json:"availableTranslatedLanguages"`
+
+		// Synopsis of the manga indexed by language code
+		Description map[string]string `json:"description"`
+
+		// Publication status, e.g. "ongoing" or "completed"
+		Status string `json:"status"`
+
+		// Genres, themes and formats of the manga
+		Tags []struct {
+			// Attributes of the tag
+			Attributes struct {
+				// Name of the tag indexed by language code
+				Name map[string]string `json:"name"`
+			} `json:"attributes"`
+		} `json:"tags"`
+	} `json:"attributes"`
+
+	// Entities related to the manga, like its cover art,
+	// only their IDs unless they're included in the request
+	Relationships []struct {
+		// Mangadex's internal UUID for the entity
+		ID string `json:"id"`
+
+		// Type of the entity, e.g. "cover_art" or "author"
+		Type string `json:"type"`
+
+		// Entity's attributes
+		Attributes struct {
+			// File name of a cover art
+			FileName string `json:"fileName"`
+
+			// Name of an author or artist
+			Name string `json:"name"`
+		} `json:"attributes"`
+	} `json:"relationships"`
+}
+
+// MangadexMangaResponse refers to the type of response
+// that gets returned by the Mangadex API when requesting
+// a single manga.
+type MangadexMangaResponse struct {
+	// Status of the request, "ok" or "error"
+	Result string `json:"result"`
+
+	// The manga requested
+	Data MangadexManga `json:"data"`
+}
+
+// MangadexChapterListResponse refers to the type of response
+// that gets returned by the Mangadex API when requesting a
+// manga's chapter feed.
+type MangadexChapterListResponse struct {
+	// Status of the request, "ok" or "error"
+	Result string `json:"result"`
+
+	// List of chapters in the feed
+	Data []MangadexChapter `json:"data"`
+}
+
+// MangadexChapter refers to a single chapter entity
+// returned by the Mangadex API.
+type MangadexChapter struct {
+	// Mangadex's internal UUID for the chapter
+	ID string `json:"id"`
+
+	// Chapter's attributes
+	Attributes struct {
+		// Chapter number, might be empty for oneshots
+		Chapter string `json:"chapter"`
+
+		// Title of the chapter
+		Title string `json:"title"`
+
+		// Language the chapter was translated to
+		TranslatedLanguage string `json:"translatedLanguage"`
+	} `json:"attributes"`
+}
+
+// MangadexLegacyMappingResponse refers to the type of response
+// that gets returned by the Mangadex API when mapping the IDs
+// of the retired v4 site to the current ones.
+type MangadexLegacyMappingResponse struct {
+	// Status of the request, "ok" or "error"
+	Result string `json:"result"`
+
+	// Mappings of the IDs requested
+	Data []struct {
+		Attributes struct {
+			// Numeric ID of the v4 site
+			LegacyID int `json:"legacyId"`
+
+			// Current UUID
+			NewID string `json:"newId"`
+		} `json:"attributes"`
+	} `json:"data"`
+}
+
+// MangaChapter is a struct used to describe
+// a single chapter (or episode) of a manga title.
+type MangaChapter struct {
+	// Title of the chapter
+	Title string `json:"title"`
+
+	// Chapter number, might be empty if it's unknown
+	Number string `json:"number"`
+
+	// URL to read the chapter
+	URL string `json:"url"`
+
+	// Language code of the chapter
+	Language string `json:"language"`
+}
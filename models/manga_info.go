@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MangaInfo is a struct used to describe a manga
+// title: its cover, author, status, genres and the
+// like. It's cached in the 'manga_info' collection.
+type MangaInfo struct {
+	// Internal ID assigned by MongoDB
+	ID primitive.ObjectID `bson:"_id,omitempty"`
+
+	// URL to the manga title, unique
+	// in the collection
+	URL string
+
+	// Code of the feed the title is from
+	Feed int
+
+	// Title of the manga
+	Title string
+
+	// Other titles the manga is known by
+	AltTitles []string
+
+	// URL to the manga's cover image
+	Cover string
+
+	// Author (or authors) of the manga
+	Author string
+
+	// Publication status, e.g. Ongoing or Completed
+	Status string
+
+	// Genres or tags of the manga
+	Genres []string
+
+	// Synopsis of the manga
+	Description string
+
+	// Latest chapters published, newest first
+	Chapters []MangaChapter
+
+	// Date the info was fetched from the feed
+	UpdatedAt time.Time
+}
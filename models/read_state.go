@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ReadState is a struct used to define the last chapter a
+// user has read of a title a chat is subscribed to. Users
+// of the same group chat keep their own read state.
+type ReadState struct {
+	// Internal ID assigned by MongoDB
+	ID primitive.ObjectID `bson:"_id,omitempty"`
+
+	// ID of the user who read the chapter
+	UserID int
+
+	// ID of the chat the subscription belongs to
+	ChatID int64
+
+	// ID of the subscription the chapter is from
+	SubscriptionID primitive.ObjectID
+
+	// URL of the last chapter read
+	ChapterURL string
+
+	// Number of the last chapter read, might be empty
+	ChapterNumber string
+
+	// Time the chapter was marked as read
+	ReadAt time.Time
+}
@@ -0,0 +1,64 @@
+package models
+
+import "encoding/xml"
+
+// RSSDocument is a struct used to decode both RSS 2.0 and
+// Atom documents. RSS documents fill the Channel property
+// while Atom documents fill Title and Entries.
+type RSSDocument struct {
+	XMLName xml.Name
+
+	// RSS channel, only present on RSS documents
+	Channel struct {
+		// Title of the channel
+		Title string `xml:"title"`
+
+		// Items published in the channel
+		Items []RSSItem `xml:"item"`
+	} `xml:"channel"`
+
+	// Title of the Atom feed
+	Title string `xml:"title"`
+
+	// Entries published in the Atom feed
+	Entries []AtomEntry `xml:"entry"`
+}
+
+// RSSItem refers to a single item
+// in a RSS channel.
+type RSSItem struct {
+	// Title of the item
+	Title string `xml:"title"`
+
+	// URL of the item
+	Link string `xml:"link"`
+
+	// Unique identifier of the item, some
+	// feeds use it as the item's URL
+	GUID string `xml:"guid"`
+
+	// Date the item was published (RFC 1123)
+	PubDate string `xml:"pubDate"`
+}
+
+// AtomEntry refers to a single entry
+// in an Atom feed.
+type AtomEntry struct {
+	// Title of the entry
+	Title string `xml:"title"`
+
+	// Links related to the entry
+	Links []struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+	} `xml:"link"`
+
+	// Unique identifier of the entry
+	ID string `xml:"id"`
+
+	// Date the entry was last updated (RFC 3339)
+	Updated string `xml:"updated"`
+
+	// Date the entry was published (RFC 3339)
+	Published string `xml:"published"`
+}
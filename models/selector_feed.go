@@ -0,0 +1,44 @@
+package models
+
+// SelectorFeedConfig is a struct used to describe an HTML
+// manga source declaratively, with URL templates and CSS
+// selectors instead of a dedicated scraper.
+type SelectorFeedConfig struct {
+	// Assigned identifier for the feed, must be
+	// 100 or higher to not clash with built-in feeds
+	Code int `yaml:"code" json:"code"`
+
+	// Name of the feed
+	Name string `yaml:"name" json:"name"`
+
+	// Feed's URL
+	URL string `yaml:"url" json:"url"`
+
+	// URL used to search mangas, the escaped
+	// query replaces its %s verb
+	SearchURL string `yaml:"search_url" json:"search_url"`
+
+	// Selector for each result in the search page
+	ResultSelector string `yaml:"result_selector" json:"result_selector"`
+
+	// Attribute of the result holding the manga title,
+	// the result's text is used when empty
+	TitleAttr string `yaml:"title_attr" json:"title_attr"`
+
+	// Attribute of the result holding the manga URL,
+	// defaults to href
+	HrefAttr string `yaml:"href_attr" json:"href_attr"`
+
+	// Selector for the chapter links in the manga
+	// page, the first match is the last chapter
+	ChapterSelector string `yaml:"chapter_selector" json:"chapter_selector"`
+
+	// Attribute of the chapter holding its URL,
+	// defaults to href
+	ChapterAttr string `yaml:"chapter_attr" json:"chapter_attr"`
+
+	// Template used to build absolute URLs out of relative
+	// ones, e.g. https://site.com%s. Required, and %s must be
+	// its only %
+	URLBase string `yaml:"url_base" json:"url_base"`
+}
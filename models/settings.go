@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ChatSettings is a struct used to define the
+// preferences of a chat. Settings are stored in
+// the chat's feed_sub document, next to the
+// fields of its FeedSubs.
+type ChatSettings struct {
+	// Internal ID assigned by MongoDB
+	ID primitive.ObjectID `bson:"_id,omitempty"`
+
+	// ID of the chat the settings belong to
+	ChatID int64 `bson:"chatid"`
+
+	// Code of the feed used for manga searches
+	Feed int `bson:"code"`
+
+	// Locale used for messages sent to the chat,
+	// detected from the user's language when empty
+	Locale string `bson:"locale"`
+
+	// Preferred chapter languages of the chat
+	Languages []string `bson:"languages"`
+
+	// Format of the new chapter alerts, one of the
+	// built-in ones or custom to use Template
+	Format string `bson:"format"`
+
+	// Custom text/template the alerts are built with
+	Template string `bson:"template"`
+
+	// Mode of the custom template: text, html or markdownv2
+	TemplateMode string `bson:"templatemode"`
+
+	// Hour (0-23) the quiet hours start at, quiet
+	// hours are off when it's equal to QuietEnd
+	QuietStart int `bson:"quietstart"`
+
+	// Hour (0-23) the quiet hours end at
+	QuietEnd int `bson:"quietend"`
+
+	// Whether link previews are disabled in alerts
+	DisablePreview bool `bson:"disablepreview"`
+
+	// IANA name of the chat's timezone (e.g. America/Santo_Domingo)
+	Timezone string `bson:"timezone"`
+
+	// How alerts are delivered: right away (instant)
+	// or in a daily or weekly digest
+	Delivery string `bson:"delivery"`
+
+	// Hour (0-23) digests are sent at
+	DigestHour int `bson:"digesthour"`
+
+	// Day of the week weekly digests are sent on
+	DigestDay time.Weekday `bson:"digestday"`
+
+	// Who can remove the subscriptions of a group
+	// chat and change its feed: admins or everyone
+	ManagedBy string `bson:"managedby"`
+}
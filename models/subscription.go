@@ -1,6 +1,8 @@
 package models
 
 import (
+	"time"
+
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -33,6 +35,50 @@ type Subscription struct {
 
 	// Feed this subscription belongs to
 	MangaFeed int
+
+	// ID of the manga series the title belongs to,
+	// empty until the subscription is linked to one
+	MangaID primitive.ObjectID `bson:"mangaid,omitempty"`
+
+	// URL to the manga's cover image
+	CoverURL string
+
+	// Whether the subscription is paused because the
+	// bot can't reach the chat (e.g. it was blocked)
+	Inactive bool
+
+	// Members of a group chat who follow the title
+	// themselves, they are mentioned in its alerts
+	Followers []Follower
+
+	// Whether the subscription only belongs to its followers,
+	// the other subscriptions of a group are shared by everyone
+	Personal bool
+
+	// When the subscription was removed, it can be restored
+	// for a while. Zero for the subscriptions in use
+	DeletedAt time.Time `bson:"deleted_at,omitempty"`
+
+	// Members who stopped following a personal subscription,
+	// they can follow it again for a while
+	Unfollowed []Follower `bson:",omitempty"`
+}
+
+// Follower is a struct used to define a member of a
+// group chat who follows one of its subscriptions.
+type Follower struct {
+	// Id of the user
+	UserID int
+
+	// Telegram username of the user, might be empty
+	UserName string
+
+	// Name of the user
+	FirstName string
+
+	// When the user stopped following the subscription,
+	// only set for the members it was unfollowed by
+	UnfollowedAt time.Time `bson:",omitempty"`
 }
 
 // FeedSubs is a struct used to define
@@ -0,0 +1,412 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/tavomoya/mangagram/actions"
+	"github.com/tavomoya/mangagram/actions/i18n"
+	"github.com/tavomoya/mangagram/models"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// quietHourPresets are the quiet hours windows the /settings menu
+// cycles through, as start and end hours. The first one means off.
+var quietHourPresets = [][2]int{{0, 0}, {22, 7}, {23, 8}, {0, 8}}
+
+// digestHourPresets are the hours the /settings
+// menu cycles through for the time of digests.
+var digestHourPresets = []int{actions.DefaultDigestHour, 12, 18, 21, 0, 6}
+
+// timezonePresets are the timezones the /settings menu cycles
+// through. Any other one can be set with the /timezone command.
+var timezonePresets = []string{
+	actions.DefaultTimezone,
+	"America/New_York",
+	"America/Mexico_City",
+	"America/Santo_Domingo",
+	"America/Bogota",
+	"America/Argentina/Buenos_Aires",
+	"Europe/London",
+	"Europe/Madrid",
+	"Asia/Tokyo",
+}
+
+// Buttons of the /settings menu. Their Unique is fixed so a
+// single handler serves the menus of every chat.
+var (
+	settingsFeedBtn      = tb.InlineButton{Unique: "settings_feed"}
+	settingsLocaleBtn    = tb.InlineButton{Unique: "settings_locale"}
+	settingsFormatBtn    = tb.InlineButton{Unique: "settings_format"}
+	settingsQuietBtn     = tb.InlineButton{Unique: "settings_quiet"}
+	settingsTimezoneBtn  = tb.InlineButton{Unique: "settings_timezone"}
+	settingsPreviewBtn   = tb.InlineButton{Unique: "settings_preview"}
+	settingsDeliveryBtn  = tb.InlineButton{Unique: "settings_delivery"}
+	settingsDigestHrBtn  = tb.InlineButton{Unique: "settings_digest_hour"}
+	settingsDigestDayBtn = tb.InlineButton{Unique: "settings_digest_day"}
+	settingsManageBtn    = tb.InlineButton{Unique: "settings_manage"}
+)
+
+// languageBtn is a button of the /language menu, its
+// Data is the locale it changes the chat's one to.
+var languageBtn = tb.InlineButton{Unique: "language"}
+
+// nextOf returns the item that follows current in
+// list, or the first one if current is not in it.
+func nextOf(list []string, current string) string {
+	for i, item := range list {
+		if item == current {
+			return list[(i+1)%len(list)]
+		}
+	}
+
+	return list[0]
+}
+
+// contains reports whether list has item.
+func contains(list []string, item string) bool {
+	for _, i := range list {
+		if i == item {
+			return true
+		}
+	}
+
+	return false
+}
+
+// quietHours returns a chat's quiet hours window
+// as text, like 22:00-07:00, in a locale.
+func quietHours(locale string, s *models.ChatSettings) string {
+	if s.QuietStart == s.QuietEnd {
+		return i18n.T(locale, "settings.off")
+	}
+
+	return fmt.Sprintf("%02d:00-%02d:00", s.QuietStart, s.QuietEnd)
+}
+
+// settingsMarkup returns the /settings menu keyboard, each
+// button shows a setting's value and changes it when tapped.
+func settingsMarkup(locale string, s *models.ChatSettings) *tb.ReplyMarkup {
+	row := func(btn tb.InlineButton, text string) []tb.InlineButton {
+		btn.Text = text
+		return []tb.InlineButton{btn}
+	}
+
+	preview := i18n.T(locale, "settings.on")
+	if s.DisablePreview {
+		preview = i18n.T(locale, "settings.off")
+	}
+
+	kb := [][]tb.InlineButton{
+		row(settingsFeedBtn, i18n.T(locale, "settings.feed", actions.FeedName(s.Feed))),
+		row(settingsLocaleBtn, i18n.T(locale, "settings.language", i18n.T(locale, "language.name"))),
+		row(settingsFormatBtn, i18n.T(locale, "settings.format", i18n.T(locale, "settings.format_"+s.Format))),
+		row(settingsDeliveryBtn, i18n.T(locale, "settings.delivery", i18n.T(locale, "settings.delivery_"+s.Delivery))),
+	}
+
+	if s.Delivery != "instant" {
+		kb = append(kb, row(settingsDigestHrBtn, i18n.T(locale, "settings.digest_hour", s.DigestHour)))
+	}
+
+	if s.Delivery == "weekly" {
+		day := i18n.T(locale, fmt.Sprintf("weekday.%d", s.DigestDay))
+		kb = append(kb, row(settingsDigestDayBtn, i18n.T(locale, "settings.digest_day", day)))
+	}
+
+	kb = append(kb,
+		row(settingsQuietBtn, i18n.T(locale, "settings.quiet", quietHours(locale, s))),
+		row(settingsTimezoneBtn, i18n.T(locale, "settings.timezone", s.Timezone)),
+		row(settingsPreviewBtn, i18n.T(locale, "settings.preview", preview)),
+	)
+
+	// Group chats have negative IDs
+	if s.ChatID < 0 {
+		kb = append(kb, row(settingsManageBtn, i18n.T(locale, "settings.managed_by", i18n.T(locale, "settings.managed_by_"+s.ManagedBy))))
+	}
+
+	return &tb.ReplyMarkup{InlineKeyboard: kb}
+}
+
+// sampleAlert is the data used to preview alert templates.
+var sampleAlert = actions.AlertData{
+	MangaName:    "One Piece",
+	MangaURL:     "https://mangadex.org/title/a1c7c817-4e59-43b7-9365-09675a149a6f",
+	Chapter:      "1000",
+	ChapterTitle: "Straw Hat Luffy",
+	ChapterURL:   "https://mangadex.org/chapter/8e7b3f2d-3a5b-4a6e-9d8e-2f1c0b7a6d5e",
+	FeedName:     "Mangadex",
+}
+
+// handleSettings registers the /settings menu
+// and the /timezone and /template command handlers.
+func handleSettings(bot *tb.Bot, db *models.DatabaseConfig) {
+
+	bot.Handle("/settings", func(m *tb.Message) {
+		locale := chatLocale(db, m.Chat, m.Sender)
+		s := actions.GetChatSettings(db, m.Chat.ID)
+
+		_, err := bot.Send(m.Chat, i18n.T(locale, "settings.title"), settingsMarkup(locale, s), tb.ModeHTML)
+		if err != nil {
+			log.Println("Unable to respond: ", err)
+		}
+	})
+
+	// change registers the handler of a menu button. The handler applies a
+	// change to the chat's settings and updates the menu in place. If
+	// allowed is not nil, only the users it allows can change the setting.
+	change := func(btn *tb.InlineButton, allowed func(c *tb.Callback) bool, apply func(s *models.ChatSettings) error) {
+		bot.Handle(btn, func(c *tb.Callback) {
+			if allowed != nil && !allowed(c) {
+				bot.Respond(c, &tb.CallbackResponse{
+					Text:      i18n.T(chatLocale(db, c.Message.Chat, c.Sender), "permissions.denied"),
+					ShowAlert: true,
+				})
+				return
+			}
+
+			s := actions.GetChatSettings(db, c.Message.Chat.ID)
+
+			err := apply(s)
+			if err != nil {
+				log.Println("There was an error changing the chat settings: ", err)
+				bot.Respond(c, &tb.CallbackResponse{
+					Text:      i18n.T(chatLocale(db, c.Message.Chat, c.Sender), "settings.error"),
+					ShowAlert: true,
+				})
+				return
+			}
+
+			locale := chatLocale(db, c.Message.Chat, c.Sender)
+			_, err = bot.Edit(c.Message, i18n.T(locale, "settings.title"), settingsMarkup(locale, s), tb.ModeHTML)
+			if err != nil {
+				log.Println("There was an error updating the settings menu: ", err)
+			}
+
+			bot.Respond(c)
+		})
+	}
+
+	// Only the chat's managers change its feed, like with /setfeed
+	managers := func(c *tb.Callback) bool {
+		return canManage(db, c.Message.Chat, c.Sender)
+	}
+
+	// Changing the feed moves every subscription to it, so it's
+	// confirmed in a dialog of its own, like with /setfeed
+	bot.Handle(&settingsFeedBtn, func(c *tb.Callback) {
+		locale := chatLocale(db, c.Message.Chat, c.Sender)
+
+		if !managers(c) {
+			bot.Respond(c, &tb.CallbackResponse{Text: i18n.T(locale, "permissions.denied"), ShowAlert: true})
+			return
+		}
+
+		s := actions.GetChatSettings(db, c.Message.Chat.ID)
+
+		feed := actions.AvailableFeeds[0]
+		for i, f := range actions.AvailableFeeds {
+			if f.Code == s.Feed {
+				feed = actions.AvailableFeeds[(i+1)%len(actions.AvailableFeeds)]
+				break
+			}
+		}
+
+		msg, markup := feedDialog(locale, feed.Code)
+		_, err := bot.Send(c.Message.Chat, msg, markup, tb.ModeHTML)
+		if err != nil {
+			log.Println("There was an error sending the feed dialog: ", err)
+		}
+
+		bot.Respond(c)
+	})
+
+	change(&settingsLocaleBtn, nil, func(s *models.ChatSettings) error {
+		s.Locale = nextOf(i18n.Locales(), s.Locale)
+		return actions.SetChatLocale(db, s.ChatID, s.Locale)
+	})
+
+	change(&settingsFormatBtn, nil, func(s *models.ChatSettings) error {
+		formats := actions.NotificationFormats
+		if s.Template == "" {
+			// Chats without a template of their own can't use the custom format
+			formats = formats[:len(formats)-1]
+		}
+
+		s.Format = nextOf(formats, s.Format)
+		return actions.SetChatSetting(db, s.ChatID, "format", s.Format)
+	})
+
+	change(&settingsDeliveryBtn, nil, func(s *models.ChatSettings) error {
+		if s.Delivery == "instant" {
+			s.DigestHour = actions.DefaultDigestHour
+			err := actions.SetChatSetting(db, s.ChatID, "digesthour", s.DigestHour)
+			if err != nil {
+				return err
+			}
+		}
+
+		s.Delivery = nextOf(actions.DeliveryModes, s.Delivery)
+		return actions.SetChatSetting(db, s.ChatID, "delivery", s.Delivery)
+	})
+
+	change(&settingsDigestHrBtn, nil, func(s *models.ChatSettings) error {
+		next := digestHourPresets[0]
+		for i, h := range digestHourPresets {
+			if h == s.DigestHour {
+				next = digestHourPresets[(i+1)%len(digestHourPresets)]
+				break
+			}
+		}
+
+		s.DigestHour = next
+		return actions.SetChatSetting(db, s.ChatID, "digesthour", s.DigestHour)
+	})
+
+	change(&settingsDigestDayBtn, nil, func(s *models.ChatSettings) error {
+		s.DigestDay = (s.DigestDay + 1) % 7
+		return actions.SetChatSetting(db, s.ChatID, "digestday", int(s.DigestDay))
+	})
+
+	change(&settingsQuietBtn, nil, func(s *models.ChatSettings) error {
+		next := quietHourPresets[0]
+		for i, p := range quietHourPresets {
+			if p[0] == s.QuietStart && p[1] == s.QuietEnd {
+				next = quietHourPresets[(i+1)%len(quietHourPresets)]
+				break
+			}
+		}
+
+		s.QuietStart, s.QuietEnd = next[0], next[1]
+
+		err := actions.SetChatSetting(db, s.ChatID, "quietstart", s.QuietStart)
+		if err != nil {
+			return err
+		}
+
+		return actions.SetChatSetting(db, s.ChatID, "quietend", s.QuietEnd)
+	})
+
+	change(&settingsTimezoneBtn, nil, func(s *models.ChatSettings) error {
+		s.Timezone = nextOf(timezonePresets, s.Timezone)
+		return actions.SetChatSetting(db, s.ChatID, "timezone", s.Timezone)
+	})
+
+	change(&settingsPreviewBtn, nil, func(s *models.ChatSettings) error {
+		s.DisablePreview = !s.DisablePreview
+		return actions.SetChatSetting(db, s.ChatID, "disablepreview", s.DisablePreview)
+	})
+
+	// Only admins choose who manages a group, whoever that is now
+	groupAdmins := func(c *tb.Callback) bool {
+		ok, err := admins.IsAdmin(c.Message.Chat, c.Sender.ID)
+		if err != nil {
+			log.Println("There was an error checking the chat's admins: ", err)
+		}
+
+		return ok
+	}
+
+	change(&settingsManageBtn, groupAdmins, func(s *models.ChatSettings) error {
+		s.ManagedBy = nextOf(actions.ManageModes, s.ManagedBy)
+		return actions.SetChatSetting(db, s.ChatID, "managedby", s.ManagedBy)
+	})
+
+	bot.Handle("/timezone", func(m *tb.Message) {
+		locale := chatLocale(db, m.Chat, m.Sender)
+
+		name := strings.TrimSpace(m.Payload)
+		if name == "" {
+			s := actions.GetChatSettings(db, m.Chat.ID)
+			bot.Send(m.Chat, i18n.T(locale, "timezone.current", s.Timezone), tb.ModeHTML)
+			return
+		}
+
+		loc, err := time.LoadLocation(name)
+		if err != nil || name == "Local" {
+			bot.Send(m.Chat, i18n.T(locale, "timezone.invalid", name))
+			return
+		}
+
+		err = actions.SetChatSetting(db, m.Chat.ID, "timezone", loc.String())
+		if err != nil {
+			log.Println("There was an error changing the chat timezone: ", err)
+			bot.Send(m.Chat, i18n.T(locale, "settings.error"))
+			return
+		}
+
+		bot.Send(m.Chat, i18n.T(locale, "timezone.saved", loc.String()), tb.ModeHTML)
+	})
+
+	bot.Handle("/template", func(m *tb.Message) {
+		locale := chatLocale(db, m.Chat, m.Sender)
+		payload := strings.TrimSpace(m.Payload)
+
+		if payload == "" {
+			s := actions.GetChatSettings(db, m.Chat.ID)
+			if s.Template != "" {
+				bot.Send(m.Chat, i18n.T(locale, "template.current", s.TemplateMode, s.Template))
+			}
+
+			bot.Send(m.Chat, i18n.T(locale, "template.help"))
+			return
+		}
+
+		if payload == "reset" {
+			err := actions.SetChatSetting(db, m.Chat.ID, "format", actions.NotificationFormats[0])
+			if err != nil {
+				log.Println("There was an error resetting the chat template: ", err)
+				bot.Send(m.Chat, i18n.T(locale, "settings.error"))
+				return
+			}
+
+			bot.Send(m.Chat, i18n.T(locale, "template.reset"))
+			return
+		}
+
+		mode, text := payload, ""
+		if i := strings.IndexAny(payload, " \n\t"); i >= 0 {
+			mode, text = payload[:i], strings.TrimSpace(payload[i:])
+		}
+
+		mode = strings.ToLower(mode)
+		if mode == "markdown" {
+			mode = actions.TemplateMarkdown
+		}
+
+		if !contains(actions.TemplateModes, mode) {
+			bot.Send(m.Chat, i18n.T(locale, "template.invalid_mode", mode))
+			return
+		}
+
+		preview, err := actions.RenderAlert(text, mode, sampleAlert)
+		if err == nil && strings.TrimSpace(preview) == "" {
+			err = errors.New("the alert is empty")
+		}
+
+		// Telegram rejects alerts with invalid markup,
+		// so the template is only saved if it can send one
+		if err == nil {
+			_, err = bot.Send(m.Chat, preview, actions.TemplateParseMode(mode))
+		}
+
+		if err != nil {
+			bot.Send(m.Chat, i18n.T(locale, "template.invalid", err.Error()))
+			return
+		}
+
+		for _, f := range [][2]string{{"template", text}, {"templatemode", mode}, {"format", "custom"}} {
+			err = actions.SetChatSetting(db, m.Chat.ID, f[0], f[1])
+			if err != nil {
+				log.Println("There was an error saving the chat template: ", err)
+				bot.Send(m.Chat, i18n.T(locale, "settings.error"))
+				return
+			}
+		}
+
+		bot.Send(m.Chat, i18n.T(locale, "template.saved"))
+	})
+}